@@ -1,14 +1,17 @@
 package handler
 
 import (
+	"log"
 	"net/http"
 	"sync"
 
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/config"
 	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/database"
 	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/routes"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/store"
 
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
 )
 
 var (
@@ -17,9 +20,30 @@ var (
 )
 
 func setupRouter() *gin.Engine {
-	_ = godotenv.Load(".env", ".env.local")
-	db := database.Connect()
-	return routes.SetupRoutes(db)
+	cfg, err := config.Load("")
+	if err != nil {
+		log.Printf("Unable to load configuration: %v", err)
+		return misconfigured()
+	}
+	for _, warning := range cfg.Warnings() {
+		log.Printf("Config: %s", warning)
+	}
+
+	keys, err := auth.LoadKeySet(cfg.JWT)
+	if err != nil {
+		log.Printf("Unable to load JWT keys: %v", err)
+		return misconfigured()
+	}
+	conn := database.New(cfg.Database)
+	return routes.SetupRoutes(cfg, conn, store.NewPostgres(conn.DB), keys)
+}
+
+func misconfigured() *gin.Engine {
+	r := gin.New()
+	r.NoRoute(func(c *gin.Context) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server configuration error"})
+	})
+	return r
 }
 
 func Handler(w http.ResponseWriter, r *http.Request) {
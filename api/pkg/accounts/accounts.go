@@ -0,0 +1,95 @@
+package accounts
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+)
+
+const DeletionGracePeriod = 30 * 24 * time.Hour
+
+var ownedTables = []string{
+	"watchlist_items",
+	"refresh_tokens",
+	"revoked_tokens",
+	"sessions",
+	"email_verification_tokens",
+	"password_reset_tokens",
+	"oauth_states",
+	"user_identities",
+	"mfa_challenges",
+	"mfa_recovery_codes",
+	"api_tokens",
+}
+
+func Purge(ctx context.Context, db *sql.DB, userID int) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var email string
+	var keepReviews bool
+	err = tx.QueryRowContext(ctx, `SELECT LOWER(email), deletion_keep_reviews FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&email, &keepReviews)
+	if err != nil {
+		return err
+	}
+
+	if keepReviews {
+		_, err = tx.ExecContext(ctx, `UPDATE reviews SET user_id = NULL WHERE user_id = $1`, userID)
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM reviews WHERE user_id = $1`, userID)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, table := range ownedTables {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM auth_throttles WHERE key = $1 OR key = $2`, email, strconv.Itoa(userID)); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `SELECT anonymise_audit_events($1)`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func PurgeDue(ctx context.Context, db *sql.DB) (int, error) {
+	rows, err := db.QueryContext(ctx, `SELECT id FROM users WHERE deletion_scheduled_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return 0, err
+	}
+
+	var userIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		userIDs = append(userIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, id := range userIDs {
+		if err := Purge(ctx, db, id); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
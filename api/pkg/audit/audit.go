@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+const writeTimeout = 5 * time.Second
+
+type Event string
+
+const (
+	EventRegistered               Event = "account.registered"
+	EventLogin                    Event = "auth.login"
+	EventLoginFailed              Event = "auth.login_failed"
+	EventSecondFactorRequired     Event = "auth.mfa_required"
+	EventLogout                   Event = "auth.logout"
+	EventRefreshTokenReuse        Event = "auth.refresh_token_reuse"
+	EventPasswordChanged          Event = "password.changed"
+	EventPasswordResetRequested   Event = "password.reset_requested"
+	EventPasswordReset            Event = "password.reset"
+	EventEmailChangeRequested     Event = "email.change_requested"
+	EventEmailVerified            Event = "email.verified"
+	EventUsernameChanged          Event = "profile.username_changed"
+	EventSessionRevoked           Event = "session.revoked"
+	EventOtherSessionsRevoked     Event = "session.others_revoked"
+	EventAPITokenCreated          Event = "api_token.created"
+	EventAPITokenRevoked          Event = "api_token.revoked"
+	EventTwoFactorEnabled         Event = "mfa.enabled"
+	EventTwoFactorDisabled        Event = "mfa.disabled"
+	EventRecoveryCodesRegenerated Event = "mfa.recovery_codes_regenerated"
+	EventIdentityLinked           Event = "identity.linked"
+	EventIdentityUnlinked         Event = "identity.unlinked"
+	EventRoleChanged              Event = "account.role_changed"
+	EventDeletionScheduled        Event = "account.deletion_scheduled"
+	EventDeletionCancelled        Event = "account.deletion_cancelled"
+	EventAccountDisabled          Event = "account.disabled"
+	EventAccountEnabled           Event = "account.enabled"
+	EventAccountDeleted           Event = "account.deleted"
+)
+
+type Entry struct {
+	Event     Event
+	UserID    *int
+	ActorID   *int
+	IPAddress string
+	UserAgent string
+	Metadata  map[string]interface{}
+}
+
+type Record struct {
+	ID        int64                  `json:"id"`
+	Event     Event                  `json:"event"`
+	UserID    *int                   `json:"userId"`
+	ActorID   *int                   `json:"actorId"`
+	IPAddress string                 `json:"ipAddress"`
+	UserAgent string                 `json:"userAgent"`
+	Metadata  map[string]interface{} `json:"metadata"`
+	CreatedAt time.Time              `json:"createdAt"`
+}
+
+type Filter struct {
+	UserID   *int
+	Event    string
+	BeforeID int64
+	Limit    int
+	Offset   int
+}
+
+type Logger struct {
+	DB *sql.DB
+}
+
+func New(db *sql.DB) *Logger {
+	return &Logger{DB: db}
+}
+
+func (l *Logger) Log(ctx context.Context, entry Entry) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), writeTimeout)
+	defer cancel()
+
+	metadata := []byte("{}")
+	if len(entry.Metadata) > 0 {
+		encoded, err := json.Marshal(entry.Metadata)
+		if err == nil {
+			metadata = encoded
+		}
+	}
+
+	query := `
+		INSERT INTO audit_events (event, user_id, actor_id, ip_address, user_agent, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := l.DB.ExecContext(ctx, query, string(entry.Event), entry.UserID, entry.ActorID, entry.IPAddress, entry.UserAgent, string(metadata))
+	if err != nil {
+		log.Printf("Failed to record audit event %s: %v", entry.Event, err)
+	}
+}
+
+func (l *Logger) Query(ctx context.Context, filter Filter) ([]Record, error) {
+	query := `
+		SELECT id, event, user_id, actor_id, ip_address, user_agent, metadata, created_at
+		FROM audit_events
+		WHERE ($1::INT IS NULL OR user_id = $1)
+			AND ($2 = '' OR event = $2 OR event LIKE $2 || '.%')
+			AND ($3 = 0 OR id < $3)
+		ORDER BY id DESC
+		LIMIT $4 OFFSET $5
+	`
+	rows, err := l.DB.QueryContext(ctx, query, filter.UserID, filter.Event, filter.BeforeID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make([]Record, 0)
+	for rows.Next() {
+		var record Record
+		var metadata []byte
+		if err := rows.Scan(&record.ID, &record.Event, &record.UserID, &record.ActorID, &record.IPAddress, &record.UserAgent, &metadata, &record.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(metadata, &record.Metadata); err != nil {
+			record.Metadata = map[string]interface{}{}
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,20 @@
+package auth
+
+import "crypto/subtle"
+
+const (
+	AccessCookieName     = "cl_access"
+	RefreshCookieName    = "cl_refresh"
+	CSRFCookieName       = "cl_csrf"
+	OAuthStateCookieName = "cl_oauth_state"
+	CSRFHeaderName       = "X-CSRF-Token"
+	SessionModeHeader    = "X-Session-Mode"
+	SessionModeCookie    = "cookie"
+)
+
+func ValidCSRFToken(cookie, header string) bool {
+	if cookie == "" || header == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) == 1
+}
@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrNoSigningKey = errors.New("no JWT signing key configured: set JWT_PRIVATE_KEYS, JWT_PRIVATE_KEYS_FILE or JWT_SECRET")
+
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.PrivateKey
+	Public  crypto.PublicKey
+}
+
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+	secret  []byte
+}
+
+func LoadKeySet(cfg config.JWT) (*KeySet, error) {
+	return NewKeySet(cfg.PrivateKeys, cfg.PublicKeys, cfg.Secret)
+}
+
+func NewKeySet(privatePEM, publicPEM, secret string) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*Key)}
+	if secret != "" {
+		ks.secret = []byte(secret)
+	}
+
+	privateKeys, err := parsePEMKeys(privatePEM, true)
+	if err != nil {
+		return nil, err
+	}
+	publicKeys, err := parsePEMKeys(publicPEM, false)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range append(privateKeys, publicKeys...) {
+		if _, exists := ks.keys[key.ID]; exists {
+			continue
+		}
+		ks.keys[key.ID] = key
+	}
+	if len(privateKeys) > 0 {
+		ks.signing = privateKeys[0]
+	}
+
+	if ks.signing == nil && ks.secret == nil {
+		return nil, ErrNoSigningKey
+	}
+	return ks, nil
+}
+
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if ks.signing == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ks.secret)
+	}
+
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	token.Header["kid"] = ks.signing.ID
+	return token.SignedString(ks.signing.Private)
+}
+
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok && ks.secret != nil {
+			return ks.secret, nil
+		}
+		return nil, fmt.Errorf("token has no key id")
+	}
+
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.Public, nil
+}
+
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(ks.keys))}
+	if ks.signing != nil {
+		set.Keys = append(set.Keys, ks.signing.JWK())
+	}
+	for id, key := range ks.keys {
+		if ks.signing != nil && id == ks.signing.ID {
+			continue
+		}
+		set.Keys = append(set.Keys, key.JWK())
+	}
+	return set
+}
+
+func (k *Key) JWK() JWK {
+	jwk, _ := NewJWK(k.Public)
+	jwk.Kid = k.ID
+	jwk.Use = "sig"
+	jwk.Alg = k.Method.Alg()
+	return jwk
+}
+
+func parsePEMKeys(data string, private bool) ([]*Key, error) {
+	var keys []*Key
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		var key *Key
+		var err error
+		if private {
+			key, err = parsePrivateKey(block)
+		} else {
+			key, err = parsePublicKey(block)
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 && strings.TrimSpace(data) != "" {
+		return nil, fmt.Errorf("no PEM encoded keys found")
+	}
+	return keys, nil
+}
+
+func parsePrivateKey(block *pem.Block) (*Key, error) {
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported private key type %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key: %w", err)
+	}
+
+	switch k := parsed.(type) {
+	case ed25519.PrivateKey:
+		return newKey(jwt.SigningMethodEdDSA, k, k.Public())
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("RSA keys must be at least 2048 bits")
+		}
+		return newKey(jwt.SigningMethodRS256, k, &k.PublicKey)
+	default:
+		return nil, fmt.Errorf("unsupported private key algorithm %T, use Ed25519 or RSA", parsed)
+	}
+}
+
+func parsePublicKey(block *pem.Block) (*Key, error) {
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported public key type %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key: %w", err)
+	}
+
+	switch k := parsed.(type) {
+	case ed25519.PublicKey:
+		return newKey(jwt.SigningMethodEdDSA, nil, k)
+	case *rsa.PublicKey:
+		return newKey(jwt.SigningMethodRS256, nil, k)
+	default:
+		return nil, fmt.Errorf("unsupported public key algorithm %T, use Ed25519 or RSA", parsed)
+	}
+}
+
+func newKey(method jwt.SigningMethod, private crypto.PrivateKey, public crypto.PublicKey) (*Key, error) {
+	jwk, err := NewJWK(public)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{ID: jwk.Thumbprint(), Method: method, Private: private, Public: public}, nil
+}
@@ -0,0 +1,67 @@
+package auth
+
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+type Permission string
+
+const (
+	PermissionModerateReviews Permission = "reviews:moderate"
+	PermissionViewUsers       Permission = "users:view"
+	PermissionManageUsers     Permission = "users:manage"
+	PermissionManageRoles     Permission = "roles:manage"
+	PermissionOperations      Permission = "ops:manage"
+	PermissionViewAuditLog    Permission = "audit:view"
+)
+
+var roleRank = map[Role]int{
+	RoleUser:      0,
+	RoleModerator: 1,
+	RoleAdmin:     2,
+}
+
+var rolePermissions = map[Role][]Permission{
+	RoleModerator: {
+		PermissionModerateReviews,
+		PermissionViewUsers,
+	},
+	RoleAdmin: {
+		PermissionModerateReviews,
+		PermissionViewUsers,
+		PermissionManageUsers,
+		PermissionManageRoles,
+		PermissionOperations,
+		PermissionViewAuditLog,
+	},
+}
+
+func ParseRole(s string) (Role, bool) {
+	role := Role(s)
+	_, ok := roleRank[role]
+	return role, ok
+}
+
+func (r Role) AtLeast(min Role) bool {
+	rank, ok := roleRank[r]
+	return ok && rank >= roleRank[min]
+}
+
+func (r Role) Can(p Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Role) Permissions() []Permission {
+	permissions := make([]Permission, len(rolePermissions[r]))
+	copy(permissions, rolePermissions[r])
+	return permissions
+}
@@ -0,0 +1,30 @@
+package auth
+
+const APITokenPrefix = "cl_pat_"
+
+type Scope string
+
+const (
+	ScopeProfileRead    Scope = "profile:read"
+	ScopeWatchlistRead  Scope = "watchlist:read"
+	ScopeWatchlistWrite Scope = "watchlist:write"
+	ScopeReviewsRead    Scope = "reviews:read"
+	ScopeReviewsWrite   Scope = "reviews:write"
+)
+
+var Scopes = []Scope{
+	ScopeProfileRead,
+	ScopeWatchlistRead,
+	ScopeWatchlistWrite,
+	ScopeReviewsRead,
+	ScopeReviewsWrite,
+}
+
+func ParseScope(s string) (Scope, bool) {
+	for _, scope := range Scopes {
+		if string(scope) == s {
+			return scope, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+type Identity struct {
+	UserID       int
+	Username     string
+	Picture      interface{}
+	Role         string
+	SessionID    string
+	TokenVersion int
+}
+
+func (ks *KeySet) NewAccessToken(identity Identity) (string, error) {
+	jti, err := GenerateToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	return ks.Sign(jwt.MapClaims{
+		"sub":      identity.UserID,
+		"username": identity.Username,
+		"pfp":      identity.Picture,
+		"role":     identity.Role,
+		"sid":      identity.SessionID,
+		"ver":      identity.TokenVersion,
+		"jti":      jti,
+		"iat":      now.Unix(),
+		"exp":      now.Add(AccessTokenTTL).Unix(),
+	})
+}
+
+func (ks *KeySet) ParseAccessToken(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, ks.Keyfunc,
+		jwt.WithValidMethods([]string{"EdDSA", "RS256", "HS256"}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, fmt.Errorf("invalid claims")
+	}
+	return claims, nil
+}
+
+func GenerateToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
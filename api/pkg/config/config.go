@@ -0,0 +1,386 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+var PasswordHashers = []string{"argon2id", "bcrypt"}
+
+type Config struct {
+	AppURL         string
+	AllowOrigins   []string
+	PasswordHasher string
+	CronSecret     string
+	Argon2         Argon2
+	Server         Server
+	Database       Database
+	JWT            JWT
+	TMDB           TMDB
+	Cloudinary     Cloudinary
+	Cookies        Cookies
+	Mail           Mail
+	OAuthProviders []OAuthProvider
+}
+
+type Argon2 struct {
+	MemoryKiB   int
+	Iterations  int
+	Parallelism int
+}
+
+type Server struct {
+	Addr            string
+	MigrateOnStart  bool
+	ShutdownTimeout time.Duration
+}
+
+type Database struct {
+	URL             string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+type JWT struct {
+	PrivateKeys string
+	PublicKeys  string
+	Secret      string
+}
+
+type TMDB struct {
+	APIKey string
+}
+
+type Cloudinary struct {
+	APIKey    string
+	APISecret string
+}
+
+func (c Cloudinary) Enabled() bool {
+	return c.APIKey != "" && c.APISecret != ""
+}
+
+type Cookies struct {
+	Domain   string
+	Insecure bool
+}
+
+type Mail struct {
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	From         string
+	LogFile      string
+}
+
+type OAuthProvider struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	DiscoveryURL string
+	Scopes       []string
+}
+
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+type loader struct {
+	files    map[string]string
+	problems []string
+}
+
+func newLoader(path string) (*loader, error) {
+	l := &loader{files: map[string]string{}}
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+
+	sources := []string{".env", ".env.local"}
+	if path != "" {
+		sources = append(sources, path)
+	}
+	for _, source := range sources {
+		values, err := godotenv.Read(source)
+		if err != nil {
+			if source == path {
+				return nil, fmt.Errorf("reading config file %s: %w", path, err)
+			}
+			continue
+		}
+		for k, v := range values {
+			l.files[k] = v
+		}
+	}
+	return l, nil
+}
+
+func Load(path string) (*Config, error) {
+	l, err := newLoader(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{
+		AppURL:         strings.TrimRight(l.get("APP_URL"), "/"),
+		AllowOrigins:   l.list("ALLOW_ORIGINS"),
+		PasswordHasher: l.getDefault("PASSWORD_HASHER", "argon2id"),
+		CronSecret:     l.secret("CRON_SECRET"),
+		Argon2: Argon2{
+			MemoryKiB:   l.int("ARGON2_MEMORY_KIB", 19*1024),
+			Iterations:  l.int("ARGON2_ITERATIONS", 2),
+			Parallelism: l.int("ARGON2_PARALLELISM", 1),
+		},
+		Server: Server{
+			Addr:            l.get("ADDR"),
+			MigrateOnStart:  l.bool("MIGRATE_ON_START", false),
+			ShutdownTimeout: l.duration("SHUTDOWN_TIMEOUT", 15*time.Second),
+		},
+		Database: l.database(),
+		JWT: JWT{
+			PrivateKeys: strings.ReplaceAll(l.secret("JWT_PRIVATE_KEYS"), `\n`, "\n"),
+			PublicKeys:  strings.ReplaceAll(l.secret("JWT_PUBLIC_KEYS"), `\n`, "\n"),
+			Secret:      l.secret("JWT_SECRET"),
+		},
+		TMDB: TMDB{
+			APIKey: l.secret("TMDB_API_KEY"),
+		},
+		Cloudinary: Cloudinary{
+			APIKey:    l.get("CLOUDINARY_API_KEY"),
+			APISecret: l.secret("CLOUDINARY_API_SECRET"),
+		},
+		Cookies: Cookies{
+			Domain:   l.get("COOKIE_DOMAIN"),
+			Insecure: l.bool("COOKIE_INSECURE", false),
+		},
+		Mail: Mail{
+			SMTPHost:     l.get("SMTP_HOST"),
+			SMTPPort:     l.getDefault("SMTP_PORT", "587"),
+			SMTPUsername: l.get("SMTP_USERNAME"),
+			SMTPPassword: l.secret("SMTP_PASSWORD"),
+			From:         l.get("MAIL_FROM"),
+			LogFile:      l.get("MAIL_LOG_FILE"),
+		},
+	}
+	if cfg.Server.Addr == "" {
+		cfg.Server.Addr = ":" + l.getDefault("PORT", "8080")
+	}
+	cfg.OAuthProviders = l.oauthProviders()
+
+	l.validate(cfg)
+	if len(l.problems) > 0 {
+		return nil, &ValidationError{Problems: l.problems}
+	}
+	return cfg, nil
+}
+
+func LoadDatabase(path string) (Database, error) {
+	l, err := newLoader(path)
+	if err != nil {
+		return Database{}, err
+	}
+
+	db := l.database()
+	if db.URL == "" {
+		l.problem("DATABASE_URL (or DATABASE_URL_FILE) is required")
+	}
+	if len(l.problems) > 0 {
+		return Database{}, &ValidationError{Problems: l.problems}
+	}
+	return db, nil
+}
+
+func (cfg *Config) Warnings() []string {
+	var warnings []string
+	if cfg.Database.URL == "" {
+		warnings = append(warnings, "DATABASE_URL is not set; database-backed routes will return 503")
+	}
+	if cfg.TMDB.APIKey == "" {
+		warnings = append(warnings, "TMDB_API_KEY is not set; movie, TV and search routes will return 503")
+	}
+	if !cfg.Cloudinary.Enabled() {
+		warnings = append(warnings, "CLOUDINARY_API_KEY and CLOUDINARY_API_SECRET are not set; profile picture uploads are disabled")
+	}
+	if cfg.Mail.SMTPHost == "" {
+		warnings = append(warnings, "SMTP_HOST is not set; emails will be written to the log")
+	}
+	if cfg.AppURL == "" {
+		warnings = append(warnings, "APP_URL is not set; verification and password reset emails will not be sent")
+	}
+	return warnings
+}
+
+func (l *loader) validate(cfg *Config) {
+	if cfg.JWT.PrivateKeys == "" && cfg.JWT.Secret == "" {
+		l.problem("JWT_PRIVATE_KEYS (or JWT_PRIVATE_KEYS_FILE) or JWT_SECRET is required")
+	}
+	if cfg.Cloudinary.APIKey != "" && cfg.Cloudinary.APISecret == "" {
+		l.problem("CLOUDINARY_API_SECRET is required when CLOUDINARY_API_KEY is set")
+	}
+	if cfg.Cloudinary.APISecret != "" && cfg.Cloudinary.APIKey == "" {
+		l.problem("CLOUDINARY_API_KEY is required when CLOUDINARY_API_SECRET is set")
+	}
+	if cfg.Mail.SMTPHost != "" && cfg.Mail.From == "" {
+		l.problem("MAIL_FROM is required when SMTP_HOST is set")
+	}
+	if cfg.Mail.SMTPHost != "" && cfg.AppURL == "" {
+		l.problem("APP_URL is required when SMTP_HOST is set")
+	}
+	if cfg.AppURL != "" {
+		if u, err := url.Parse(cfg.AppURL); err != nil || u.Scheme == "" || u.Host == "" {
+			l.problem(fmt.Sprintf("APP_URL must be an absolute URL, got %q", cfg.AppURL))
+		}
+	}
+
+	known := false
+	for _, name := range PasswordHashers {
+		known = known || cfg.PasswordHasher == name
+	}
+	if !known {
+		l.problem(fmt.Sprintf("PASSWORD_HASHER must be one of %s, got %q", strings.Join(PasswordHashers, ", "), cfg.PasswordHasher))
+	}
+	if cfg.Argon2.Iterations < 1 {
+		l.problem("ARGON2_ITERATIONS must be at least 1")
+	}
+	if cfg.Argon2.Parallelism < 1 || cfg.Argon2.Parallelism > 255 {
+		l.problem(fmt.Sprintf("ARGON2_PARALLELISM must be between 1 and 255, got %d", cfg.Argon2.Parallelism))
+	}
+	if cfg.Argon2.MemoryKiB < 8*cfg.Argon2.Parallelism || cfg.Argon2.MemoryKiB > 4*1024*1024 {
+		l.problem(fmt.Sprintf("ARGON2_MEMORY_KIB must be between 8 per ARGON2_PARALLELISM thread and 4194304, got %d", cfg.Argon2.MemoryKiB))
+	}
+}
+
+func (l *loader) database() Database {
+	return Database{
+		URL:             l.secret("DATABASE_URL"),
+		MaxOpenConns:    l.int("DB_MAX_OPEN_CONNS", 10),
+		MaxIdleConns:    l.int("DB_MAX_IDLE_CONNS", 2),
+		ConnMaxLifetime: l.duration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		ConnMaxIdleTime: l.duration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+	}
+}
+
+func (l *loader) oauthProviders() []OAuthProvider {
+	var providers []OAuthProvider
+	for _, name := range l.list("OAUTH_PROVIDERS") {
+		name = strings.ToLower(name)
+		prefix := "OAUTH_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		provider := OAuthProvider{
+			Name:         name,
+			ClientID:     l.get(prefix + "CLIENT_ID"),
+			ClientSecret: l.secret(prefix + "CLIENT_SECRET"),
+			DiscoveryURL: l.get(prefix + "DISCOVERY_URL"),
+		}
+		if scopes := l.get(prefix + "SCOPES"); scopes != "" {
+			provider.Scopes = strings.Fields(strings.ReplaceAll(scopes, ",", " "))
+		}
+
+		if provider.ClientID == "" {
+			l.problem(prefix + "CLIENT_ID is required because OAUTH_PROVIDERS lists " + name)
+		}
+		if provider.ClientSecret == "" {
+			l.problem(prefix + "CLIENT_SECRET is required because OAUTH_PROVIDERS lists " + name)
+		}
+		if provider.DiscoveryURL == "" && name != "github" && name != "google" {
+			l.problem(prefix + "DISCOVERY_URL is required for provider " + name)
+		}
+		providers = append(providers, provider)
+	}
+	return providers
+}
+
+func (l *loader) problem(message string) {
+	l.problems = append(l.problems, message)
+}
+
+func (l *loader) get(name string) string {
+	if value, ok := os.LookupEnv(name); ok {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(l.files[name])
+}
+
+func (l *loader) getDefault(name, fallback string) string {
+	if value := l.get(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func (l *loader) secret(name string) string {
+	if value := l.get(name); value != "" {
+		return value
+	}
+	path := l.get(name + "_FILE")
+	if path == "" {
+		return ""
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		l.problem(fmt.Sprintf("%s_FILE: %v", name, err))
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func (l *loader) list(name string) []string {
+	var values []string
+	for _, value := range strings.Split(l.get(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func (l *loader) int(name string, fallback int) int {
+	raw := l.get(name)
+	if raw == "" {
+		return fallback
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		l.problem(fmt.Sprintf("%s must be a non-negative integer, got %q", name, raw))
+		return fallback
+	}
+	return value
+}
+
+func (l *loader) bool(name string, fallback bool) bool {
+	raw := l.get(name)
+	if raw == "" {
+		return fallback
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		l.problem(fmt.Sprintf("%s must be true or false, got %q", name, raw))
+		return fallback
+	}
+	return value
+}
+
+func (l *loader) duration(name string, fallback time.Duration) time.Duration {
+	raw := l.get(name)
+	if raw == "" {
+		return fallback
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil || value < 0 {
+		l.problem(fmt.Sprintf("%s must be a duration such as 30s or 5m, got %q", name, raw))
+		return fallback
+	}
+	return value
+}
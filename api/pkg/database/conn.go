@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/config"
+)
+
+const (
+	StateUp           = "up"
+	StateUnavailable  = "unavailable"
+	StateUnconfigured = "unconfigured"
+)
+
+type Status struct {
+	State     string     `json:"status"`
+	CheckedAt *time.Time `json:"checkedAt,omitempty"`
+}
+
+type Conn struct {
+	DB *sql.DB
+
+	mu        sync.Mutex
+	ready     bool
+	checking  bool
+	failures  int
+	checkedAt time.Time
+}
+
+func New(cfg config.Database) *Conn {
+	db, err := Open(cfg)
+	if err != nil {
+		log.Printf("Database disabled: %v", err)
+		return &Conn{}
+	}
+
+	conn := &Conn{DB: db}
+	conn.Ready(context.Background())
+	return conn
+}
+
+func (c *Conn) Configured() bool {
+	return c.DB != nil
+}
+
+func (c *Conn) Ready(ctx context.Context) bool {
+	if c.DB == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	if c.checking || time.Since(c.checkedAt) < c.retryDelay() {
+		ready := c.ready
+		c.mu.Unlock()
+		return ready
+	}
+	c.checking = true
+	c.mu.Unlock()
+
+	return c.ping(ctx)
+}
+
+func (c *Conn) Status(ctx context.Context) Status {
+	if c.DB == nil {
+		return Status{State: StateUnconfigured}
+	}
+
+	state := StateUnavailable
+	if c.Ready(ctx) {
+		state = StateUp
+	}
+
+	c.mu.Lock()
+	checkedAt := c.checkedAt
+	c.mu.Unlock()
+	return Status{State: state, CheckedAt: &checkedAt}
+}
+
+func (c *Conn) ping(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	err := c.DB.PingContext(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checking = false
+	c.checkedAt = time.Now()
+	if err != nil {
+		if c.ready || c.failures == 0 {
+			log.Printf("Database unavailable: %v", err)
+		}
+		c.ready = false
+		c.failures++
+		return false
+	}
+
+	if !c.ready {
+		log.Println("Database connection successful.")
+	}
+	c.ready = true
+	c.failures = 0
+	return true
+}
+
+func (c *Conn) retryDelay() time.Duration {
+	if c.ready {
+		return recheckInterval
+	}
+	if c.failures == 0 {
+		return 0
+	}
+	delay := time.Second << (c.failures - 1)
+	if delay > maxRetryDelay || delay <= 0 {
+		delay = maxRetryDelay
+	}
+	return delay
+}
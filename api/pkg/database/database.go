@@ -1,28 +1,51 @@
 package database
 
 import (
+	"context"
 	"database/sql"
-	"log"
-	"os"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/config"
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
-func Connect() *sql.DB {
-	dsn := os.Getenv("DATABASE_URL")
-	if dsn == "" {
-		log.Fatal("DATABASE_URL environment variable not set")
+const (
+	pingTimeout     = 5 * time.Second
+	recheckInterval = 30 * time.Second
+	maxRetryDelay   = 30 * time.Second
+)
+
+var ErrNotConfigured = errors.New("DATABASE_URL is not configured")
+
+func Open(cfg config.Database) (*sql.DB, error) {
+	if cfg.URL == "" {
+		return nil, ErrNotConfigured
 	}
 
-	db, err := sql.Open("pgx", dsn)
+	db, err := sql.Open("pgx", cfg.URL)
 	if err != nil {
-		log.Fatalf("Unable to connect to database: %v\n", err)
+		return nil, err
 	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	return db, nil
+}
 
-	if err := db.Ping(); err != nil {
-		log.Fatalf("Database ping failed: %v\n", err)
+func Connect(ctx context.Context, cfg config.Database) (*sql.DB, error) {
+	db, err := Open(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	log.Println("Database connection successful.")
-	return db
-}
\ No newline at end of file
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("database ping failed: %w", err)
+	}
+	return db, nil
+}
@@ -0,0 +1,8 @@
+DELETE FROM reviews WHERE user_id IS NULL;
+ALTER TABLE reviews ALTER COLUMN user_id SET NOT NULL;
+
+DROP INDEX IF EXISTS users_deletion_scheduled_idx;
+ALTER TABLE users
+    DROP COLUMN IF EXISTS deletion_keep_reviews,
+    DROP COLUMN IF EXISTS deletion_scheduled_at,
+    DROP COLUMN IF EXISTS deletion_requested_at;
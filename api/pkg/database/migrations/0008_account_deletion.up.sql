@@ -0,0 +1,8 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS deletion_requested_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS deletion_scheduled_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS deletion_keep_reviews BOOLEAN NOT NULL DEFAULT TRUE;
+
+CREATE INDEX IF NOT EXISTS users_deletion_scheduled_idx ON users (deletion_scheduled_at) WHERE deletion_scheduled_at IS NOT NULL;
+
+ALTER TABLE reviews ALTER COLUMN user_id DROP NOT NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS username_history;
+DROP INDEX IF EXISTS users_username_lower_key;
@@ -0,0 +1,22 @@
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(format('%s (user ids %s)', email, ids), '; ')
+    INTO duplicates
+    FROM (
+        SELECT LOWER(TRIM(email)) AS email, string_agg(id::TEXT, ', ' ORDER BY id) AS ids
+        FROM users
+        GROUP BY LOWER(TRIM(email))
+        HAVING COUNT(*) > 1
+    ) d;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'Duplicate accounts differ only by email case, merge or rename them before enforcing case-insensitive emails: %', duplicates;
+    END IF;
+END $$;
+
+UPDATE users SET email = LOWER(TRIM(email)) WHERE email <> LOWER(TRIM(email));
+UPDATE email_verification_tokens SET email = LOWER(TRIM(email)) WHERE email <> LOWER(TRIM(email));
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_lower_key ON users (LOWER(email));
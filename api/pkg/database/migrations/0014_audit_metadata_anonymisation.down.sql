@@ -0,0 +1,25 @@
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND current_setting('cinelume.audit_anonymise', true) = 'on' THEN
+        NEW := OLD;
+        NEW.ip_address := '';
+        NEW.user_agent := '';
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION anonymise_audit_events(target INTEGER) RETURNS BIGINT AS $$
+DECLARE
+    affected BIGINT;
+BEGIN
+    PERFORM set_config('cinelume.audit_anonymise', 'on', true);
+    UPDATE audit_events SET ip_address = '', user_agent = ''
+    WHERE (user_id = target OR actor_id = target)
+        AND (ip_address <> '' OR user_agent <> '');
+    GET DIAGNOSTICS affected = ROW_COUNT;
+    PERFORM set_config('cinelume.audit_anonymise', 'off', true);
+    RETURN affected;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,37 @@
+-- Anonymising an event also drops the metadata keys that can carry a
+-- username or email address. Failed logins for unknown accounts have no
+-- user_id, so they are matched on the identifier that was typed instead.
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND current_setting('cinelume.audit_anonymise', true) = 'on' THEN
+        NEW := OLD;
+        NEW.ip_address := '';
+        NEW.user_agent := '';
+        NEW.metadata := OLD.metadata - ARRAY['identifier', 'email', 'username', 'from', 'to'];
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION anonymise_audit_events(target INTEGER) RETURNS BIGINT AS $$
+DECLARE
+    identifiers TEXT[];
+    affected BIGINT;
+BEGIN
+    identifiers := ARRAY(
+        SELECT LOWER(email) FROM users WHERE id = target
+        UNION SELECT LOWER(username) FROM users WHERE id = target
+        UNION SELECT LOWER(username) FROM username_history WHERE user_id = target
+    );
+
+    PERFORM set_config('cinelume.audit_anonymise', 'on', true);
+    UPDATE audit_events SET ip_address = '', user_agent = ''
+    WHERE ((user_id = target OR actor_id = target)
+            AND (ip_address <> '' OR user_agent <> '' OR metadata ?| ARRAY['identifier', 'email', 'username', 'from', 'to']))
+        OR (user_id IS NULL AND LOWER(metadata->>'identifier') = ANY(identifiers));
+    GET DIAGNOSTICS affected = ROW_COUNT;
+    PERFORM set_config('cinelume.audit_anonymise', 'off', true);
+    RETURN affected;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const Commands = `  up           apply all pending migrations
+  down [n]     revert the last n applied migrations (default 1)
+  status       list migrations and when they were applied`
+
+var ErrUnknownCommand = errors.New("migrations: unknown command")
+
+func RunCommand(ctx context.Context, db *sql.DB, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return ErrUnknownCommand
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := Up(ctx, db)
+		if err != nil {
+			return fmt.Errorf("migration failed: %w", err)
+		}
+		fmt.Fprintf(w, "Applied %d migration(s)\n", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid step count %q", args[1])
+			}
+			steps = n
+		}
+		reverted, err := Down(ctx, db, steps)
+		if err != nil {
+			return fmt.Errorf("rollback failed: %w", err)
+		}
+		fmt.Fprintf(w, "Reverted %d migration(s)\n", reverted)
+	case "status":
+		statuses, err := List(ctx, db)
+		if err != nil {
+			return fmt.Errorf("unable to read migration status: %w", err)
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(w, "%04d  %-45s %s\n", s.Version, s.Name, applied)
+		}
+	default:
+		return ErrUnknownCommand
+	}
+	return nil
+}
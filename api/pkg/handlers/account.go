@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/accounts"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/audit"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/mailer"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const recentSignInWindow = 10 * time.Minute
+
+type ExportedWatchlistItem struct {
+	MediaID    int       `json:"mediaId"`
+	MediaType  string    `json:"mediaType"`
+	Title      string    `json:"title"`
+	PosterPath string    `json:"posterPath"`
+	Status     string    `json:"status"`
+	AddedAt    time.Time `json:"addedAt"`
+}
+
+type ExportedReview struct {
+	ID              int       `json:"id"`
+	MediaID         int       `json:"mediaId"`
+	MediaType       string    `json:"mediaType"`
+	MediaTitle      string    `json:"mediaTitle"`
+	MediaPosterPath string    `json:"mediaPosterPath"`
+	Rating          int       `json:"rating"`
+	Comment         string    `json:"comment"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+type AccountExport struct {
+	ExportedAt time.Time               `json:"exportedAt"`
+	Profile    models.User             `json:"profile"`
+	Watchlist  []ExportedWatchlistItem `json:"watchlist"`
+	Reviews    []ExportedReview        `json:"reviews"`
+	Sessions   []Session               `json:"sessions"`
+	Identities []LinkedIdentity        `json:"identities"`
+	APITokens  []APIToken              `json:"apiTokens"`
+}
+
+func (h *UserHandler) ExportData(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	export, err := h.buildExport(c.Request.Context(), userID.(int), c.GetString("sessionID"))
+	if err != nil {
+		log.Printf("Failed to export data for user %v: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export account data"})
+		return
+	}
+
+	filename := fmt.Sprintf("cinelume-%s-%s", export.Profile.Username, export.ExportedAt.Format("20060102"))
+
+	if c.DefaultQuery("format", "json") != "zip" {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+		c.JSON(http.StatusOK, export)
+		return
+	}
+
+	archive, err := exportArchive(export)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export account data"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, filename))
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+func (h *UserHandler) buildExport(ctx context.Context, userID int, currentSessionID string) (*AccountExport, error) {
+	export := &AccountExport{
+		ExportedAt: time.Now().UTC(),
+		Watchlist:  make([]ExportedWatchlistItem, 0),
+		Reviews:    make([]ExportedReview, 0),
+		Sessions:   make([]Session, 0),
+		Identities: make([]LinkedIdentity, 0),
+		APITokens:  make([]APIToken, 0),
+	}
+
+	profile := &export.Profile
+	query := `
+		SELECT id, username, email, email_verified_at IS NOT NULL, totp_enabled_at IS NOT NULL, role, profile_picture_url, description, created_at, deletion_scheduled_at
+		FROM users WHERE id = $1
+	`
+	err := h.DB.QueryRowContext(ctx, query, userID).Scan(&profile.ID, &profile.Username, &profile.Email, &profile.EmailVerified, &profile.TwoFactorEnabled, &profile.Role, &profile.ProfilePictureURL, &profile.Description, &profile.CreatedAt, &profile.DeletionScheduledAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := h.DB.QueryContext(ctx, `SELECT media_id, media_type, title, poster_path, status, added_at FROM watchlist_items WHERE user_id = $1 ORDER BY added_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var item ExportedWatchlistItem
+		var posterPath sql.NullString
+		if err := rows.Scan(&item.MediaID, &item.MediaType, &item.Title, &posterPath, &item.Status, &item.AddedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		item.PosterPath = posterPath.String
+		export.Watchlist = append(export.Watchlist, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	query = `
+		SELECT id, media_id, media_type, media_title, media_poster_path, rating, comment, created_at, updated_at
+		FROM reviews WHERE user_id = $1 ORDER BY created_at
+	`
+	rows, err = h.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var review ExportedReview
+		var posterPath, comment sql.NullString
+		if err := rows.Scan(&review.ID, &review.MediaID, &review.MediaType, &review.MediaTitle, &posterPath, &review.Rating, &comment, &review.CreatedAt, &review.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		review.MediaPosterPath = posterPath.String
+		review.Comment = comment.String
+		export.Reviews = append(export.Reviews, review)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	query = `
+		SELECT id, user_agent, ip_address, created_at, last_seen_at
+		FROM sessions WHERE user_id = $1 AND revoked_at IS NULL ORDER BY created_at
+	`
+	rows, err = h.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var session Session
+		if err := rows.Scan(&session.ID, &session.UserAgent, &session.IPAddress, &session.CreatedAt, &session.LastSeenAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		session.Current = session.ID == currentSessionID
+		export.Sessions = append(export.Sessions, session)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = h.DB.QueryContext(ctx, `SELECT provider, email, created_at, last_login_at FROM user_identities WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var identity LinkedIdentity
+		var email sql.NullString
+		if err := rows.Scan(&identity.Provider, &email, &identity.CreatedAt, &identity.LastLoginAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		identity.Email = email.String
+		export.Identities = append(export.Identities, identity)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	query = `
+		SELECT id, name, token_prefix, scopes, created_at, last_used_at, expires_at
+		FROM api_tokens WHERE user_id = $1 AND revoked_at IS NULL ORDER BY created_at
+	`
+	rows, err = h.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var token APIToken
+		var scopes string
+		if err := rows.Scan(&token.ID, &token.Name, &token.Prefix, &scopes, &token.CreatedAt, &token.LastUsedAt, &token.ExpiresAt); err != nil {
+			return nil, err
+		}
+		token.Scopes = strings.Fields(scopes)
+		export.APITokens = append(export.APITokens, token)
+	}
+
+	return export, rows.Err()
+}
+
+func exportArchive(export *AccountExport) ([]byte, error) {
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"watchlist.json", export.Watchlist},
+		{"reviews.json", export.Reviews},
+		{"sessions.json", export.Sessions},
+		{"identities.json", export.Identities},
+		{"api_tokens.json", export.APITokens},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: export.ExportedAt})
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (h *UserHandler) confirmAccountOwner(ctx context.Context, userID int, sessionID string, payload models.DeleteAccountPayload) (bool, error) {
+	var passwordHash string
+	var twoFactorEnabled bool
+	var sessionCreatedAt time.Time
+	query := `
+		SELECT u.password_hash, u.totp_enabled_at IS NOT NULL, s.created_at
+		FROM users u
+		JOIN sessions s ON s.user_id = u.id
+		WHERE u.id = $1 AND s.id = $2
+	`
+	if err := h.DB.QueryRowContext(ctx, query, userID, sessionID).Scan(&passwordHash, &twoFactorEnabled, &sessionCreatedAt); err != nil {
+		return false, err
+	}
+
+	if passwordHash != "" {
+		if !h.checkPassword(ctx, userID, passwordHash, payload.Password) {
+			return false, nil
+		}
+	} else if time.Since(sessionCreatedAt) > recentSignInWindow {
+		return false, nil
+	}
+
+	if !twoFactorEnabled {
+		return true, nil
+	}
+	return h.verifySecondFactor(ctx, userID, payload.Code, payload.RecoveryCode)
+}
+
+func (h *UserHandler) DeleteAccount(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	sessionID := c.GetString("sessionID")
+
+	var payload models.DeleteAccountPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accountKey := accountThrottleKey(userID.(int))
+	if h.checkThrottle(c, accountKey) {
+		return
+	}
+	ok, err := h.confirmAccountOwner(c.Request.Context(), userID.(int), sessionID, payload)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify credentials"})
+		return
+	}
+	if !ok {
+		h.recordAttempt(c, accountKey)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Could not confirm your identity. Check your password and verification code, or sign in again."})
+		return
+	}
+	h.resetThrottle(c, accountKey)
+
+	user, err := h.Users.ScheduleDeletion(c.Request.Context(), userID.(int), time.Now().Add(accounts.DeletionGracePeriod), !payload.DeleteReviews)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule account deletion"})
+		return
+	}
+
+	if _, err := h.revokeOtherSessions(c.Request.Context(), userID.(int), sessionID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign out other sessions"})
+		return
+	}
+	h.DB.ExecContext(c.Request.Context(), `UPDATE api_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+
+	settingsURL, err := h.emailLink("/profile/settings", nil)
+	if err == nil {
+		err = h.Mailer.Send(mailer.Message{
+			To:      user.Email,
+			Subject: "Your CineLume account is scheduled for deletion",
+			Body: fmt.Sprintf(
+				"Hi %s,\n\nYour CineLume account will be permanently deleted on %s.\n\nIf you change your mind, sign in before then and restore your account from your settings:\n\n%s\n\nIf you did not request this, sign in, restore your account and change your password immediately.\n",
+				user.Username, user.DeletionScheduledAt.UTC().Format("January 2, 2006"), settingsURL,
+			),
+		})
+	}
+	if err != nil {
+		log.Printf("Failed to send deletion notice to user %v: %v", userID, err)
+	}
+
+	h.audit(c, audit.EventDeletionScheduled, userID, map[string]interface{}{"scheduledAt": user.DeletionScheduledAt, "deleteReviews": payload.DeleteReviews})
+	c.JSON(http.StatusOK, gin.H{
+		"message":             "Your account is scheduled for deletion",
+		"deletionScheduledAt": user.DeletionScheduledAt,
+	})
+}
+
+func (h *UserHandler) RestoreAccount(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	restored, err := h.Users.CancelDeletion(c.Request.Context(), userID.(int))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore account"})
+		return
+	}
+	if !restored {
+		c.JSON(http.StatusConflict, gin.H{"error": "Account is not scheduled for deletion"})
+		return
+	}
+
+	h.audit(c, audit.EventDeletionCancelled, userID, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Account deletion cancelled"})
+}
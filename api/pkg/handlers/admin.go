@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/accounts"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/audit"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/maintenance"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/models"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	DB    *sql.DB
+	Users store.UserStore
+	Audit *audit.Logger
+}
+
+func NewAdminHandler(db *sql.DB, users store.UserStore) *AdminHandler {
+	return &AdminHandler{DB: db, Users: users, Audit: audit.New(db)}
+}
+
+type AdminUser struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (h *AdminHandler) Me(c *gin.Context) {
+	role := auth.Role(c.GetString("role"))
+	c.JSON(http.StatusOK, gin.H{
+		"role":        role,
+		"permissions": role.Permissions(),
+	})
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 200 {
+		limit = 50
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	query := `
+		SELECT id, username, email, role, created_at
+		FROM users
+		WHERE $1 = '' OR username ILIKE '%' || $1 || '%' ESCAPE '\' OR email ILIKE '%' || $1 || '%' ESCAPE '\'
+		ORDER BY id
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := h.DB.QueryContext(c.Request.Context(), query, likeEscaper.Replace(c.Query("query")), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
+		return
+	}
+	defer rows.Close()
+
+	users := make([]AdminUser, 0)
+	for rows.Next() {
+		var user AdminUser
+		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.CreatedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan user"})
+			return
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
+	actorID := c.GetInt("userID")
+	if actorID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var payload models.UpdateRolePayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, ok := auth.ParseRole(payload.Role)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown role"})
+		return
+	}
+	if targetID == actorID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot change your own role"})
+		return
+	}
+
+	if _, err := h.Users.SetRole(c.Request.Context(), targetID, string(role)); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+
+	recordAudit(h.Audit, c, audit.EventRoleChanged, targetID, map[string]interface{}{"role": role})
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated successfully", "role": role})
+}
+
+func (h *AdminHandler) PurgeDeletedAccounts(c *gin.Context) {
+	purged, err := accounts.PurgeDue(c.Request.Context(), h.DB)
+	if err != nil {
+		log.Printf("Failed to purge deleted accounts after %d: %v", purged, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge deleted accounts", "purged": purged})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"purged": purged})
+}
+
+func (h *AdminHandler) RunScheduledPurge(c *gin.Context) {
+	results, err := maintenance.PurgeExpired(c.Request.Context(), h.DB)
+	if err != nil {
+		log.Printf("Scheduled purge of expired rows failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge expired rows"})
+		return
+	}
+	expired := map[string]int64{}
+	for _, r := range results {
+		expired[r.Table] = r.Rows
+	}
+
+	purged, err := accounts.PurgeDue(c.Request.Context(), h.DB)
+	if err != nil {
+		log.Printf("Scheduled purge of deleted accounts failed after %d: %v", purged, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge deleted accounts", "expired": expired, "purged": purged})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"expired": expired, "purged": purged})
+}
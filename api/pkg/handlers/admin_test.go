@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/store"
+)
+
+func TestLikeEscaperQuotesWildcards(t *testing.T) {
+	for input, want := range map[string]string{
+		"alice":    "alice",
+		"100%":     `100\%`,
+		"a_b":      `a\_b`,
+		`back\end`: `back\\end`,
+	} {
+		if got := likeEscaper.Replace(input); got != want {
+			t.Errorf("likeEscaper.Replace(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestUpdateUserRoleRequiresActor(t *testing.T) {
+	stores := store.NewMemory()
+	target := createTestUser(t, stores, "target")
+	h := NewAdminHandler(nil, stores.Users)
+	path := "/admin/users/" + strconv.Itoa(target) + "/role"
+
+	anonymous := newTestRouter(0)
+	anonymous.PUT("/admin/users/:id/role", h.UpdateUserRole)
+	expectStatus(t, serve(t, anonymous, http.MethodPut, path, map[string]string{"role": "moderator"}), http.StatusUnauthorized)
+
+	self := newTestRouter(target)
+	self.PUT("/admin/users/:id/role", h.UpdateUserRole)
+	expectStatus(t, serve(t, self, http.MethodPut, path, map[string]string{"role": "moderator"}), http.StatusBadRequest)
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/audit"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxAPITokensPerUser = 20
+
+type APIToken struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+}
+
+func (h *UserHandler) ListAPITokens(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	query := `
+		SELECT id, name, token_prefix, scopes, created_at, last_used_at, expires_at
+		FROM api_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+		ORDER BY created_at DESC
+	`
+	rows, err := h.DB.QueryContext(c.Request.Context(), query, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve API tokens"})
+		return
+	}
+	defer rows.Close()
+
+	tokens := make([]APIToken, 0)
+	for rows.Next() {
+		var token APIToken
+		var scopes string
+		if err := rows.Scan(&token.ID, &token.Name, &token.Prefix, &scopes, &token.CreatedAt, &token.LastUsedAt, &token.ExpiresAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan API token"})
+			return
+		}
+		token.Scopes = strings.Fields(scopes)
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve API tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *UserHandler) CreateAPIToken(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var payload models.CreateAPITokenPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	scopes := make([]string, 0, len(payload.Scopes))
+	for _, s := range payload.Scopes {
+		scope, ok := auth.ParseScope(s)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown scope: " + s})
+			return
+		}
+		scopes = append(scopes, string(scope))
+	}
+
+	var active int
+	query := `SELECT COUNT(*) FROM api_tokens WHERE user_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)`
+	if err := h.DB.QueryRowContext(c.Request.Context(), query, userID).Scan(&active); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API token"})
+		return
+	}
+	if active >= maxAPITokensPerUser {
+		c.JSON(http.StatusConflict, gin.H{"error": "Too many active API tokens, revoke one first"})
+		return
+	}
+
+	secret, err := auth.GenerateToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API token"})
+		return
+	}
+	plaintext := auth.APITokenPrefix + secret
+
+	var expiresAt *time.Time
+	if payload.ExpiresInDays > 0 {
+		t := time.Now().Add(time.Duration(payload.ExpiresInDays) * 24 * time.Hour)
+		expiresAt = &t
+	}
+
+	token := APIToken{
+		Name:      payload.Name,
+		Prefix:    plaintext[:len(auth.APITokenPrefix)+6],
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	query = `
+		INSERT INTO api_tokens (user_id, name, token_prefix, token_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	err = h.DB.QueryRowContext(c.Request.Context(), query, userID, token.Name, token.Prefix, auth.HashToken(plaintext), strings.Join(scopes, " "), expiresAt).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API token"})
+		return
+	}
+
+	h.audit(c, audit.EventAPITokenCreated, userID, map[string]interface{}{"tokenId": token.ID, "name": token.Name, "scopes": scopes})
+	c.JSON(http.StatusCreated, gin.H{
+		"token":    plaintext,
+		"apiToken": token,
+		"message":  "Store this token now, it will not be shown again",
+	})
+}
+
+func (h *UserHandler) RevokeAPIToken(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	tokenID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		return
+	}
+
+	res, err := h.DB.ExecContext(c.Request.Context(), `UPDATE api_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, tokenID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API token"})
+		return
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API token not found"})
+		return
+	}
+
+	h.audit(c, audit.EventAPITokenRevoked, userID, map[string]interface{}{"tokenId": tokenID})
+	c.JSON(http.StatusOK, gin.H{"message": "API token revoked successfully"})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+func recordAudit(logger *audit.Logger, c *gin.Context, event audit.Event, userID interface{}, metadata map[string]interface{}) {
+	entry := audit.Entry{
+		Event:     event,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Metadata:  metadata,
+	}
+	if id, ok := userID.(int); ok {
+		entry.UserID = &id
+	}
+	if actorID, ok := c.Get("userID"); ok {
+		if id, ok := actorID.(int); ok {
+			entry.ActorID = &id
+		}
+	}
+	logger.Log(c.Request.Context(), entry)
+}
+
+func (h *UserHandler) audit(c *gin.Context, event audit.Event, userID interface{}, metadata map[string]interface{}) {
+	recordAudit(h.Audit, c, event, userID, metadata)
+}
+
+func auditPage(c *gin.Context, maxLimit int) (int, int) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > maxLimit {
+		limit = 50
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+func (h *UserHandler) ListSecurityEvents(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := userID.(int)
+	limit, offset := auditPage(c, 100)
+
+	events, err := h.Audit.Query(c.Request.Context(), audit.Filter{UserID: &id, Event: c.Query("event"), Limit: limit, Offset: offset})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve security events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+func (h *AdminHandler) ListAuditEvents(c *gin.Context) {
+	filter := audit.Filter{Event: c.Query("event")}
+	filter.Limit, filter.Offset = auditPage(c, 200)
+
+	if raw := c.Query("userId"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		filter.UserID = &id
+	}
+	if raw := c.Query("before"); raw != "" {
+		before, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		filter.BeforeID = before
+	}
+
+	events, err := h.Audit.Query(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve audit events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+func (h *UserHandler) auditLogin(c *gin.Context, userID int, method string, response gin.H) {
+	event := audit.EventLogin
+	if _, challenged := response["challengeToken"]; challenged {
+		event = audit.EventSecondFactorRequired
+	}
+	h.audit(c, event, userID, map[string]interface{}{"method": method})
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+const refreshCookiePath = "/api/users"
+
+func wantsCookieSession(c *gin.Context) bool {
+	return c.GetHeader(auth.SessionModeHeader) == auth.SessionModeCookie
+}
+
+func (h *UserHandler) setAuthCookie(c *gin.Context, name, value, path string, maxAge int, httpOnly bool, sameSite http.SameSite) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   h.Config.Cookies.Domain,
+		MaxAge:   maxAge,
+		Secure:   !h.Config.Cookies.Insecure,
+		HttpOnly: httpOnly,
+		SameSite: sameSite,
+	})
+}
+
+func (h *UserHandler) setSessionCookies(c *gin.Context, accessToken, refreshToken string) (string, error) {
+	csrfToken, err := auth.GenerateToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	refreshMaxAge := int(auth.RefreshTokenTTL.Seconds())
+	h.setAuthCookie(c, auth.AccessCookieName, accessToken, "/", int(auth.AccessTokenTTL.Seconds()), true, http.SameSiteLaxMode)
+	h.setAuthCookie(c, auth.RefreshCookieName, refreshToken, refreshCookiePath, refreshMaxAge, true, http.SameSiteStrictMode)
+	h.setAuthCookie(c, auth.CSRFCookieName, csrfToken, "/", refreshMaxAge, false, http.SameSiteLaxMode)
+	return csrfToken, nil
+}
+
+func (h *UserHandler) clearSessionCookies(c *gin.Context) {
+	h.setAuthCookie(c, auth.AccessCookieName, "", "/", -1, true, http.SameSiteLaxMode)
+	h.setAuthCookie(c, auth.RefreshCookieName, "", refreshCookiePath, -1, true, http.SameSiteStrictMode)
+	h.setAuthCookie(c, auth.CSRFCookieName, "", "/", -1, false, http.SameSiteLaxMode)
+}
+
+func validCSRFToken(c *gin.Context) bool {
+	cookie, _ := c.Cookie(auth.CSRFCookieName)
+	return auth.ValidCSRFToken(cookie, c.GetHeader(auth.CSRFHeaderName))
+}
+
+func (h *UserHandler) deliverSession(c *gin.Context, response gin.H, useCookies bool) (gin.H, error) {
+	accessToken, ok := response["token"].(string)
+	if !ok || !useCookies {
+		return response, nil
+	}
+
+	refreshToken, _ := response["refreshToken"].(string)
+	csrfToken, err := h.setSessionCookies(c, accessToken, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return gin.H{
+		"sessionMode": auth.SessionModeCookie,
+		"csrfToken":   csrfToken,
+		"expiresIn":   response["expiresIn"],
+	}, nil
+}
+
+func (h *UserHandler) attachAccessToken(c *gin.Context, response gin.H, accessToken string) {
+	if c.GetBool("cookieSession") {
+		h.setAuthCookie(c, auth.AccessCookieName, accessToken, "/", int(auth.AccessTokenTTL.Seconds()), true, http.SameSiteLaxMode)
+		return
+	}
+	response["token"] = accessToken
+}
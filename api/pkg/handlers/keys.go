@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+type KeysHandler struct {
+	Keys *auth.KeySet
+}
+
+func NewKeysHandler(keys *auth.KeySet) *KeysHandler {
+	return &KeysHandler{Keys: keys}
+}
+
+func (h *KeysHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.Keys.JWKS())
+}
@@ -1,28 +1,39 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+	"strconv"
 	"sync"
 
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/middleware"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/store"
 	"github.com/gin-gonic/gin"
 )
 
 type MovieHandler struct {
-	DB *sql.DB
+	APIKey    string
+	Reviews   store.ReviewStore
+	Watchlist store.WatchlistStore
 }
 
-func NewMovieHandler(db *sql.DB) *MovieHandler {
-	return &MovieHandler{DB: db}
+func NewMovieHandler(apiKey string, reviews store.ReviewStore, watchlist store.WatchlistStore) *MovieHandler {
+	return &MovieHandler{APIKey: apiKey, Reviews: reviews, Watchlist: watchlist}
 }
 
 func (h *MovieHandler) GetMovieDetails(c *gin.Context) {
 	movieID := c.Param("id")
-	apiKey := os.Getenv("TMDB_API_KEY")
+	mediaID, err := strconv.Atoi(movieID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		return
+	}
+	if !requireTMDB(c, h.APIKey) {
+		return
+	}
+	apiKey := h.APIKey
 
 	urls := map[string]string{
 		"details":         fmt.Sprintf("https://api.themoviedb.org/3/movie/%s?api_key=%s&language=en-US", movieID, apiKey),
@@ -54,43 +65,17 @@ func (h *MovieHandler) GetMovieDetails(c *gin.Context) {
 		}(key, url)
 	}
 	wg.Wait()
-	
-	reviewQuery := `
-		SELECT r.id, r.rating, r.comment, r.created_at, u.username, u.profile_picture_url
-		FROM reviews r
-		JOIN users u ON r.user_id = u.id
-		WHERE r.media_id = $1 AND r.media_type = 'movie'
-		ORDER BY r.created_at DESC
-	`
-	rows, err := h.DB.Query(reviewQuery, movieID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+
+	if c.GetBool(middleware.DatabaseUnavailableKey) {
+		results["reviews"] = json.RawMessage("null")
+		c.JSON(http.StatusOK, results)
 		return
 	}
-	defer rows.Close()
 
-	var reviews []gin.H
-	for rows.Next() {
-		var review struct {
-			ID                int
-			Rating            int
-			Comment           string
-			CreatedAt         string
-			Username          string
-			ProfilePictureURL sql.NullString
-		}
-		if err := rows.Scan(&review.ID, &review.Rating, &review.Comment, &review.CreatedAt, &review.Username, &review.ProfilePictureURL); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan review"})
-			return
-		}
-		reviews = append(reviews, gin.H{
-			"id":                review.ID,
-			"rating":            review.Rating,
-			"comment":           review.Comment,
-			"createdAt":         review.CreatedAt,
-			"username":          review.Username,
-			"profilePictureUrl": review.ProfilePictureURL.String,
-		})
+	reviews, err := mediaReviews(c.Request.Context(), h.Reviews, mediaID, "movie")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+		return
 	}
 
 	results["reviews"] = func() json.RawMessage {
@@ -98,5 +83,13 @@ func (h *MovieHandler) GetMovieDetails(c *gin.Context) {
 		return json.RawMessage(b)
 	}()
 
+	if userID, ok := c.Get("userID"); ok {
+		viewer, err := viewerMediaState(c.Request.Context(), h.Reviews, h.Watchlist, userID.(int), mediaID, "movie")
+		if err == nil {
+			b, _ := json.Marshal(viewer)
+			results["viewer"] = json.RawMessage(b)
+		}
+	}
+
 	c.JSON(http.StatusOK, results)
 }
\ No newline at end of file
@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/audit"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/oidc"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/store"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/usernames"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	oauthStateTTL        = 10 * time.Minute
+	oauthStateCookiePath = "/api/auth"
+)
+
+var (
+	errIdentityLinkedElsewhere = errors.New("This account is already linked to another CineLume user")
+	errUnverifiedEmailConflict = errors.New("An account with this email already exists. Log in with your password and link this provider from your settings")
+	errMissingEmail            = errors.New("The provider did not share an email address")
+)
+
+type OAuthHandler struct {
+	*UserHandler
+	Providers map[string]oidc.Provider
+}
+
+func NewOAuthHandler(users *UserHandler, providers map[string]oidc.Provider) *OAuthHandler {
+	return &OAuthHandler{UserHandler: users, Providers: providers}
+}
+
+type LinkedIdentity struct {
+	Provider    string     `json:"provider"`
+	Email       string     `json:"email"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	LastLoginAt *time.Time `json:"lastLoginAt"`
+}
+
+func (h *OAuthHandler) ListProviders(c *gin.Context) {
+	names := make([]string, 0, len(h.Providers))
+	for name := range h.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	c.JSON(http.StatusOK, gin.H{"providers": names})
+}
+
+func (h *OAuthHandler) callbackURL(c *gin.Context, provider string) string {
+	return h.appURL(c, "/api/auth/"+provider+"/callback", nil)
+}
+
+func (h *OAuthHandler) beginAuth(c *gin.Context, provider oidc.Provider, linkUserID interface{}, cookieSession bool) (string, error) {
+	state, err := auth.GenerateToken(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := auth.GenerateToken(16)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := auth.GenerateToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	query := `
+		INSERT INTO oauth_states (state_hash, provider, nonce, code_verifier, user_id, cookie_session, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err = h.DB.ExecContext(c.Request.Context(), query, auth.HashToken(state), provider.Name(), nonce, verifier, linkUserID, cookieSession, time.Now().Add(oauthStateTTL))
+	if err != nil {
+		return "", err
+	}
+
+	h.DB.ExecContext(c.Request.Context(), `DELETE FROM oauth_states WHERE expires_at < CURRENT_TIMESTAMP`)
+	h.setAuthCookie(c, auth.OAuthStateCookieName, state, oauthStateCookiePath, int(oauthStateTTL.Seconds()), true, http.SameSiteLaxMode)
+
+	return provider.AuthCodeURL(c.Request.Context(), h.callbackURL(c, provider.Name()), state, nonce, verifier)
+}
+
+func (h *OAuthHandler) Login(c *gin.Context) {
+	provider, ok := h.Providers[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		return
+	}
+
+	authURL, err := h.beginAuth(c, provider, nil, c.Query("session") == auth.SessionModeCookie)
+	if err != nil {
+		log.Printf("Failed to start %s login: %v", provider.Name(), err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to start login with provider"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+func (h *OAuthHandler) LinkIdentity(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	provider, ok := h.Providers[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		return
+	}
+
+	authURL, err := h.beginAuth(c, provider, userID, false)
+	if err != nil {
+		log.Printf("Failed to start %s link: %v", provider.Name(), err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to start login with provider"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"url": authURL})
+}
+
+func (h *OAuthHandler) Callback(c *gin.Context) {
+	provider, ok := h.Providers[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		return
+	}
+
+	fail := func(message string) {
+		c.Redirect(http.StatusFound, h.appURL(c, "/login", url.Values{"error": {message}}))
+	}
+
+	stateCookie, _ := c.Cookie(auth.OAuthStateCookieName)
+	h.setAuthCookie(c, auth.OAuthStateCookieName, "", oauthStateCookiePath, -1, true, http.SameSiteLaxMode)
+
+	if c.Query("error") != "" {
+		fail("Sign in was cancelled or denied by the provider")
+		return
+	}
+	if stateCookie == "" || subtle.ConstantTimeCompare([]byte(stateCookie), []byte(c.Query("state"))) != 1 {
+		fail("Sign in was started in a different browser, please try again")
+		return
+	}
+
+	var nonce, verifier string
+	var linkUserID sql.NullInt64
+	var cookieSession bool
+	query := `
+		DELETE FROM oauth_states
+		WHERE state_hash = $1 AND provider = $2 AND expires_at > CURRENT_TIMESTAMP
+		RETURNING nonce, code_verifier, user_id, cookie_session
+	`
+	err := h.DB.QueryRowContext(c.Request.Context(), query, auth.HashToken(c.Query("state")), provider.Name()).Scan(&nonce, &verifier, &linkUserID, &cookieSession)
+	if err != nil {
+		fail("Sign in session expired, please try again")
+		return
+	}
+
+	identity, err := provider.Exchange(c.Request.Context(), h.callbackURL(c, provider.Name()), c.Query("code"), nonce, verifier)
+	if err != nil {
+		log.Printf("OAuth callback for %s failed: %v", provider.Name(), err)
+		fail("Could not verify your identity with the provider")
+		return
+	}
+
+	userID, err := h.resolveIdentity(c.Request.Context(), provider.Name(), identity, linkUserID)
+	if err != nil {
+		if errors.Is(err, errIdentityLinkedElsewhere) || errors.Is(err, errUnverifiedEmailConflict) || errors.Is(err, errMissingEmail) {
+			fail(err.Error())
+			return
+		}
+		log.Printf("Failed to resolve %s identity: %v", provider.Name(), err)
+		fail("Could not sign you in")
+		return
+	}
+
+	if linkUserID.Valid {
+		h.audit(c, audit.EventIdentityLinked, userID, map[string]interface{}{"provider": provider.Name()})
+		c.Redirect(http.StatusFound, h.appURL(c, "/profile/settings", url.Values{"linked": {provider.Name()}}))
+		return
+	}
+
+	response, err := h.completeLogin(c, userID)
+	if err == nil {
+		h.auditLogin(c, userID, provider.Name(), response)
+		response, err = h.deliverSession(c, response, cookieSession)
+	}
+	if errors.Is(err, errAccountDisabled) {
+		fail(err.Error())
+		return
+	}
+	if err != nil {
+		fail("Could not sign you in")
+		return
+	}
+
+	// Redirect URLs end up in browser history, so the fragment never carries a
+	// refresh token. Cookie sessions get no tokens in it at all.
+	fragment := url.Values{}
+	for _, key := range []string{"token", "challengeToken", "sessionMode"} {
+		if value, ok := response[key].(string); ok {
+			fragment.Set(key, value)
+		}
+	}
+	c.Redirect(http.StatusFound, h.appURL(c, "/auth/callback", nil)+"#"+fragment.Encode())
+}
+
+func (h *OAuthHandler) resolveIdentity(ctx context.Context, provider string, identity *oidc.Identity, linkUserID sql.NullInt64) (int, error) {
+	var userID int
+	err := h.DB.QueryRowContext(ctx, `SELECT user_id FROM user_identities WHERE provider = $1 AND subject = $2`, provider, identity.Subject).Scan(&userID)
+	if err == nil {
+		if linkUserID.Valid && int(linkUserID.Int64) != userID {
+			return 0, errIdentityLinkedElsewhere
+		}
+		h.DB.ExecContext(ctx, `UPDATE user_identities SET last_login_at = CURRENT_TIMESTAMP, email = $3 WHERE provider = $1 AND subject = $2`, provider, identity.Subject, identity.Email)
+		return userID, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	if linkUserID.Valid {
+		return int(linkUserID.Int64), h.insertIdentity(ctx, int(linkUserID.Int64), provider, identity)
+	}
+
+	if identity.Email == "" {
+		return 0, errMissingEmail
+	}
+
+	var emailVerified bool
+	err = h.DB.QueryRowContext(ctx, `SELECT id, email_verified_at IS NOT NULL FROM users WHERE LOWER(email) = $1`, normalizeEmail(identity.Email)).Scan(&userID, &emailVerified)
+	if err == nil {
+		if !emailVerified || !identity.EmailVerified {
+			return 0, errUnverifiedEmailConflict
+		}
+		return userID, h.insertIdentity(ctx, userID, provider, identity)
+	}
+	if err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	username, err := h.availableUsername(ctx, identity)
+	if err != nil {
+		return 0, err
+	}
+
+	return h.Users.CreateFromIdentity(ctx, store.ExternalAccount{
+		Username:      username,
+		Email:         normalizeEmail(identity.Email),
+		Picture:       identity.Picture,
+		EmailVerified: identity.EmailVerified,
+		Provider:      provider,
+		Subject:       identity.Subject,
+		ProviderEmail: identity.Email,
+	})
+}
+
+func (h *OAuthHandler) insertIdentity(ctx context.Context, userID int, provider string, identity *oidc.Identity) error {
+	query := `
+		INSERT INTO user_identities (user_id, provider, subject, email, last_login_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id, provider) DO UPDATE SET subject = EXCLUDED.subject, email = EXCLUDED.email, last_login_at = CURRENT_TIMESTAMP
+	`
+	_, err := h.DB.ExecContext(ctx, query, userID, provider, identity.Subject, identity.Email)
+	return err
+}
+
+func (h *OAuthHandler) availableUsername(ctx context.Context, identity *oidc.Identity) (string, error) {
+	base := ""
+	for _, candidate := range []string{identity.PreferredUsername, identity.Name, strings.Split(identity.Email, "@")[0]} {
+		if base = sanitizeUsername(candidate); base != "" {
+			break
+		}
+	}
+	if base == "" {
+		base = "user"
+	}
+
+	if len(base) > usernames.MaxLength-4 {
+		base = strings.TrimRight(base[:usernames.MaxLength-4], "_")
+	}
+
+	candidate := base
+	for i := 0; i < 10; i++ {
+		if usernames.Validate(candidate) == nil {
+			taken, err := h.Users.UsernameTaken(ctx, candidate, 0)
+			if err != nil {
+				return "", err
+			}
+			if !taken {
+				return candidate, nil
+			}
+		}
+		candidate = fmt.Sprintf("%s%d", base, 1000+rand.IntN(9000))
+	}
+	return "", fmt.Errorf("could not find a free username for %q", base)
+}
+
+func sanitizeUsername(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.':
+			b.WriteRune('_')
+		}
+		if b.Len() >= 24 {
+			break
+		}
+	}
+	return strings.Trim(b.String(), "_")
+}
+
+func (h *OAuthHandler) ListIdentities(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	rows, err := h.DB.QueryContext(c.Request.Context(), `SELECT provider, email, created_at, last_login_at FROM user_identities WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve linked accounts"})
+		return
+	}
+	defer rows.Close()
+
+	identities := make([]LinkedIdentity, 0)
+	for rows.Next() {
+		var identity LinkedIdentity
+		var email sql.NullString
+		if err := rows.Scan(&identity.Provider, &email, &identity.CreatedAt, &identity.LastLoginAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan linked account"})
+			return
+		}
+		identity.Email = email.String
+		identities = append(identities, identity)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve linked accounts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, identities)
+}
+
+func (h *OAuthHandler) UnlinkIdentity(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	provider := c.Param("provider")
+
+	var hasPassword bool
+	var identities int
+	query := `
+		SELECT u.password_hash <> '', (SELECT COUNT(*) FROM user_identities WHERE user_id = u.id)
+		FROM users u WHERE u.id = $1
+	`
+	if err := h.DB.QueryRowContext(c.Request.Context(), query, userID).Scan(&hasPassword, &identities); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink account"})
+		return
+	}
+	if !hasPassword && identities <= 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Set a password before removing your only sign-in method"})
+		return
+	}
+
+	res, err := h.DB.ExecContext(c.Request.Context(), `DELETE FROM user_identities WHERE user_id = $1 AND provider = $2`, userID, provider)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink account"})
+		return
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Linked account not found"})
+		return
+	}
+
+	h.audit(c, audit.EventIdentityUnlinked, userID, map[string]interface{}{"provider": provider})
+	c.JSON(http.StatusOK, gin.H{"message": "Account unlinked successfully"})
+}
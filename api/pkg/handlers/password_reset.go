@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/audit"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/mailer"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/models"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/store"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/throttle"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	passwordResetTTL         = time.Hour
+	passwordResetMaxPerEmail = 3
+	passwordResetWindow      = time.Hour
+	passwordResetMinDuration = 3 * time.Second
+)
+
+func (h *UserHandler) RequestPasswordReset(c *gin.Context) {
+	var payload models.ForgotPasswordPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ipKey := throttleKey{throttle.PasswordResetIP, c.ClientIP()}
+	if h.checkThrottle(c, ipKey) {
+		return
+	}
+	h.recordAttempt(c, ipKey)
+
+	started := time.Now()
+	if err := h.sendPasswordReset(c, normalizeEmail(payload.Email)); err != nil {
+		log.Printf("Failed to process password reset request: %v", err)
+	}
+	padResponse(c, started, passwordResetMinDuration)
+
+	c.JSON(http.StatusOK, gin.H{"message": "If an account exists for that email, a password reset link has been sent."})
+}
+
+// padResponse holds the response until at least minimum has passed since
+// started, so the time taken does not reveal whether an account exists.
+func padResponse(c *gin.Context, started time.Time, minimum time.Duration) {
+	remaining := minimum - time.Since(started)
+	if remaining <= 0 {
+		return
+	}
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-c.Request.Context().Done():
+	}
+}
+
+func (h *UserHandler) sendPasswordReset(c *gin.Context, email string) error {
+	if h.Config.AppURL == "" {
+		return errAppURLNotConfigured
+	}
+
+	var userID int
+	err := h.DB.QueryRowContext(c.Request.Context(), `SELECT id FROM users WHERE LOWER(email) = $1`, email).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	recent, err := h.Users.CountPasswordResets(c.Request.Context(), userID, time.Now().Add(-passwordResetWindow))
+	if err != nil {
+		return err
+	}
+	if recent >= passwordResetMaxPerEmail {
+		return nil
+	}
+
+	token, err := auth.GenerateToken(32)
+	if err != nil {
+		return err
+	}
+
+	if err := h.Users.CreatePasswordReset(c.Request.Context(), userID, auth.HashToken(token), time.Now().Add(passwordResetTTL)); err != nil {
+		return err
+	}
+	h.audit(c, audit.EventPasswordResetRequested, userID, nil)
+
+	link, err := h.emailLink("/reset-password", url.Values{"token": {token}})
+	if err != nil {
+		return err
+	}
+	return h.Mailer.Send(mailer.Message{
+		To:      email,
+		Subject: "Reset your CineLume password",
+		Body: fmt.Sprintf("Hi,\n\nSomeone asked to reset the password for your CineLume account. Open the link below to choose a new one:\n\n%s\n\nThe link can be used once and expires in %d minutes. If you didn't request this, you can ignore this email and your password will stay the same.\n",
+			link, int(passwordResetTTL.Minutes())),
+	})
+}
+
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	var payload models.ResetPasswordPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	newHashedPassword, err := h.Passwords.Hash(payload.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash new password"})
+		return
+	}
+
+	userID, err := h.Users.ResetPassword(c.Request.Context(), auth.HashToken(payload.Token), newHashedPassword)
+	if errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	h.audit(c, audit.EventPasswordReset, userID, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset. Please log in with your new password."})
+}
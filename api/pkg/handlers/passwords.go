@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"context"
+	"log"
+)
+
+func (h *UserHandler) checkPassword(ctx context.Context, userID int, encoded, password string) bool {
+	ok, rehash, err := h.Passwords.Verify(encoded, password)
+	if err != nil {
+		log.Printf("Failed to verify password for user %d: %v", userID, err)
+		return false
+	}
+	if !ok || !rehash {
+		return ok
+	}
+
+	upgraded, err := h.Passwords.Hash(password)
+	if err != nil {
+		log.Printf("Failed to rehash password for user %d: %v", userID, err)
+		return true
+	}
+	if err := h.Users.ReplacePasswordHash(ctx, userID, encoded, upgraded); err != nil {
+		log.Printf("Failed to store rehashed password for user %d: %v", userID, err)
+	}
+	return true
+}
@@ -1,19 +1,19 @@
 package handlers
 
 import (
-	"database/sql"
 	"net/http"
 	"strconv"
 
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/store"
 	"github.com/gin-gonic/gin"
 )
 
 type ReviewHandler struct {
-	DB *sql.DB
+	Reviews store.ReviewStore
 }
 
-func NewReviewHandler(db *sql.DB) *ReviewHandler {
-	return &ReviewHandler{DB: db}
+func NewReviewHandler(reviews store.ReviewStore) *ReviewHandler {
+	return &ReviewHandler{Reviews: reviews}
 }
 
 type ReviewPayload struct {
@@ -26,7 +26,7 @@ type ReviewPayload struct {
 }
 
 func (h *ReviewHandler) AddReview(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	userID := c.GetInt("userID")
 
 	var payload ReviewPayload
 	if err := c.ShouldBindJSON(&payload); err != nil {
@@ -34,13 +34,14 @@ func (h *ReviewHandler) AddReview(c *gin.Context) {
 		return
 	}
 
-	query := `
-		INSERT INTO reviews (user_id, media_id, media_type, rating, comment, media_title, media_poster_path)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		ON CONFLICT (user_id, media_id, media_type)
-		DO UPDATE SET rating = EXCLUDED.rating, comment = EXCLUDED.comment, updated_at = CURRENT_TIMESTAMP
-	`
-	_, err := h.DB.Exec(query, userID, payload.MediaID, payload.MediaType, payload.Rating, payload.Comment, payload.MediaTitle, payload.MediaPosterPath)
+	err := h.Reviews.Upsert(c.Request.Context(), userID, store.Review{
+		MediaID:         payload.MediaID,
+		MediaType:       payload.MediaType,
+		MediaTitle:      payload.MediaTitle,
+		MediaPosterPath: payload.MediaPosterPath,
+		Rating:          payload.Rating,
+		Comment:         payload.Comment,
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add or update review"})
 		return
@@ -50,7 +51,7 @@ func (h *ReviewHandler) AddReview(c *gin.Context) {
 }
 
 func (h *ReviewHandler) UpdateReview(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	userID := c.GetInt("userID")
 	reviewID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
@@ -66,21 +67,44 @@ func (h *ReviewHandler) UpdateReview(c *gin.Context) {
 		return
 	}
 
-	query := `
-		UPDATE reviews SET rating = $1, comment = $2, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $3 AND user_id = $4
-	`
-	res, err := h.DB.Exec(query, payload.Rating, payload.Comment, reviewID, userID)
+	updated, err := h.Reviews.Update(c.Request.Context(), userID, reviewID, payload.Rating, payload.Comment)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update review"})
 		return
 	}
-
-	rowsAffected, _ := res.RowsAffected()
-	if rowsAffected == 0 {
+	if !updated {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You can only edit your own reviews"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Review updated successfully"})
-}
\ No newline at end of file
+}
+
+func (h *ReviewHandler) ListReviews(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	list, err := h.Reviews.ListByUser(c.Request.Context(), c.GetInt("userID"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reviews"})
+		return
+	}
+
+	reviews := make([]gin.H, 0, len(list))
+	for _, review := range list {
+		reviews = append(reviews, gin.H{
+			"id":              review.ID,
+			"mediaId":         review.MediaID,
+			"mediaType":       review.MediaType,
+			"mediaTitle":      review.MediaTitle,
+			"mediaPosterPath": review.MediaPosterPath,
+			"rating":          review.Rating,
+			"comment":         review.Comment,
+			"createdAt":       review.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/store"
+)
+
+func TestReviewAddUpdateAndList(t *testing.T) {
+	stores := store.NewMemory()
+	userID := createTestUser(t, stores, "alice")
+	h := NewReviewHandler(stores.Reviews)
+
+	router := newTestRouter(userID)
+	router.POST("/reviews", h.AddReview)
+	router.PUT("/reviews/:id", h.UpdateReview)
+	router.GET("/reviews", h.ListReviews)
+
+	rec := serve(t, router, http.MethodPost, "/reviews", ReviewPayload{MediaID: 7, MediaType: "movie", MediaTitle: "Film", Rating: 6, Comment: "fine"})
+	expectStatus(t, rec, http.StatusCreated)
+
+	review, err := stores.Reviews.FindByUserMedia(context.Background(), userID, 7, "movie")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec = serve(t, router, http.MethodPut, "/reviews/"+strconv.Itoa(review.ID), map[string]interface{}{"rating": 9, "comment": "better on rewatch"})
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = serve(t, router, http.MethodGet, "/reviews", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var reviews []struct {
+		MediaID int    `json:"mediaId"`
+		Rating  int    `json:"rating"`
+		Comment string `json:"comment"`
+	}
+	decode(t, rec, &reviews)
+	if len(reviews) != 1 || reviews[0].MediaID != 7 || reviews[0].Rating != 9 || reviews[0].Comment != "better on rewatch" {
+		t.Fatalf("reviews = %+v", reviews)
+	}
+}
+
+func TestReviewRejectsOutOfRangeRating(t *testing.T) {
+	stores := store.NewMemory()
+	h := NewReviewHandler(stores.Reviews)
+	router := newTestRouter(createTestUser(t, stores, "alice"))
+	router.POST("/reviews", h.AddReview)
+
+	rec := serve(t, router, http.MethodPost, "/reviews", ReviewPayload{MediaID: 7, MediaType: "movie", MediaTitle: "Film", Rating: 11})
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestReviewUpdateOnlyOwn(t *testing.T) {
+	stores := store.NewMemory()
+	h := NewReviewHandler(stores.Reviews)
+	owner := createTestUser(t, stores, "owner")
+	other := createTestUser(t, stores, "other")
+
+	if err := stores.Reviews.Upsert(context.Background(), owner, store.Review{MediaID: 3, MediaType: "tv", MediaTitle: "Show", Rating: 5}); err != nil {
+		t.Fatal(err)
+	}
+	review, err := stores.Reviews.FindByUserMedia(context.Background(), owner, 3, "tv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := newTestRouter(other)
+	router.PUT("/reviews/:id", h.UpdateReview)
+	rec := serve(t, router, http.MethodPut, "/reviews/"+strconv.Itoa(review.ID), map[string]interface{}{"rating": 1})
+	expectStatus(t, rec, http.StatusForbidden)
+}
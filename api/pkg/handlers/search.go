@@ -4,26 +4,29 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 
 	"github.com/gin-gonic/gin"
 )
 
-type SearchHandler struct{}
+type SearchHandler struct {
+	APIKey string
+}
 
-func NewSearchHandler() *SearchHandler {
-	return &SearchHandler{}
+func NewSearchHandler(apiKey string) *SearchHandler {
+	return &SearchHandler{APIKey: apiKey}
 }
 
 func (h *SearchHandler) Search(c *gin.Context) {
+	if !requireTMDB(c, h.APIKey) {
+		return
+	}
 	query := c.Query("query")
 	if query == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter is required"})
 		return
 	}
 
-	apiKey := os.Getenv("TMDB_API_KEY")
-	searchURL := fmt.Sprintf("https://api.themoviedb.org/3/search/multi?api_key=%s&query=%s", apiKey, query)
+	searchURL := fmt.Sprintf("https://api.themoviedb.org/3/search/multi?api_key=%s&query=%s", h.APIKey, query)
 
 	resp, err := http.Get(searchURL)
 	if err != nil {
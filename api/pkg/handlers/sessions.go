@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/audit"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Session struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IPAddress  string    `json:"ipAddress"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	Current    bool      `json:"current"`
+}
+
+func (h *UserHandler) createSession(c *gin.Context, userID int) (string, error) {
+	sessionID, err := auth.GenerateToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	query := `INSERT INTO sessions (id, user_id, user_agent, ip_address) VALUES ($1, $2, $3, $4)`
+	_, err = h.DB.ExecContext(c.Request.Context(), query, sessionID, userID, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		return "", err
+	}
+	return sessionID, nil
+}
+
+func (h *UserHandler) revokeSession(ctx context.Context, userID int, sessionID string) error {
+	tx, err := h.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, sessionID, userID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE session_id = $1 AND user_id = $2 AND revoked_at IS NULL`, sessionID, userID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (h *UserHandler) revokeOtherSessions(ctx context.Context, userID int, currentSessionID string) (int64, error) {
+	tx, err := h.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL`, userID, currentSessionID)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND session_id <> $2 AND revoked_at IS NULL`, userID, currentSessionID)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	revoked, _ := res.RowsAffected()
+	return revoked, nil
+}
+
+func (h *UserHandler) ListSessions(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	currentSessionID := c.GetString("sessionID")
+
+	query := `
+		SELECT id, user_agent, ip_address, created_at, last_seen_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY last_seen_at DESC
+	`
+	rows, err := h.DB.QueryContext(c.Request.Context(), query, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sessions"})
+		return
+	}
+	defer rows.Close()
+
+	sessions := make([]Session, 0)
+	for rows.Next() {
+		var session Session
+		var userAgent, ipAddress sql.NullString
+		if err := rows.Scan(&session.ID, &userAgent, &ipAddress, &session.CreatedAt, &session.LastSeenAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan session"})
+			return
+		}
+		session.UserAgent = userAgent.String
+		session.IPAddress = ipAddress.String
+		session.Current = session.ID == currentSessionID
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *UserHandler) RevokeSession(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	sessionID := c.Param("id")
+
+	var exists bool
+	err := h.DB.QueryRowContext(c.Request.Context(), `SELECT EXISTS(SELECT 1 FROM sessions WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL)`, sessionID, userID).Scan(&exists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	if err := h.revokeSession(c.Request.Context(), userID.(int), sessionID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	h.audit(c, audit.EventSessionRevoked, userID, map[string]interface{}{"sessionId": sessionID})
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+func (h *UserHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	revoked, err := h.revokeOtherSessions(c.Request.Context(), userID.(int), c.GetString("sessionID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	h.audit(c, audit.EventOtherSessionsRevoked, userID, map[string]interface{}{"revoked": revoked})
+	c.JSON(http.StatusOK, gin.H{"message": "Other sessions revoked successfully", "revoked": revoked})
+}
@@ -1,18 +1,23 @@
 package handlers
 
 import (
-	"database/sql"
+	"errors"
 	"net/http"
+	"net/url"
+	"strings"
 
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/store"
 	"github.com/gin-gonic/gin"
 )
 
 type StatsHandler struct {
-	DB *sql.DB
+	Users     store.UserStore
+	Watchlist store.WatchlistStore
+	Reviews   store.ReviewStore
 }
 
-func NewStatsHandler(db *sql.DB) *StatsHandler {
-	return &StatsHandler{DB: db}
+func NewStatsHandler(users store.UserStore, watchlist store.WatchlistStore, reviews store.ReviewStore) *StatsHandler {
+	return &StatsHandler{Users: users, Watchlist: watchlist, Reviews: reviews}
 }
 
 type WatchlistStatItem struct {
@@ -20,103 +25,110 @@ type WatchlistStatItem struct {
 	Count  int    `json:"count"`
 }
 
+// ProfileViewer describes the signed-in viewer's relationship to the profile.
+// Only isSelf exists for now; there are no follows to report until users can
+// follow each other.
+type ProfileViewer struct {
+	IsSelf bool `json:"isSelf"`
+}
+
 type UserStats struct {
+	Username       string              `json:"username"`
 	WatchlistStats []WatchlistStatItem `json:"watchlistStats"`
 	MeanScore      float64             `json:"meanScore"`
 	TotalEntries   int                 `json:"totalEntries"`
 	ReviewsCount   int                 `json:"reviewsCount"`
+	Viewer         *ProfileViewer      `json:"viewer,omitempty"`
 }
 
-func (h *StatsHandler) GetUserStats(c *gin.Context) {
-	username := c.Param("username")
-	var userID int
-	
-	userQuery := `SELECT id FROM users WHERE username = $1`
-	err := h.DB.QueryRow(userQuery, username).Scan(&userID)
+func (h *StatsHandler) resolveUser(c *gin.Context) (int, string, bool) {
+	requested := c.Param("username")
+
+	userID, username, err := h.Users.ResolveUsername(c.Request.Context(), requested)
+	if err == nil {
+		return userID, username, true
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		return 0, "", false
+	}
+
+	username, err = h.Users.UsernameRedirect(c.Request.Context(), requested)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return 0, "", false
+	}
+
+	location := strings.Replace(c.Request.URL.Path, "/users/"+requested+"/", "/users/"+url.PathEscape(username)+"/", 1)
+	if c.Request.URL.RawQuery != "" {
+		location += "?" + c.Request.URL.RawQuery
+	}
+	c.Header("Location", location)
+	c.JSON(http.StatusFound, gin.H{"error": "User has been renamed", "username": username})
+	return 0, "", false
+}
+
+func (h *StatsHandler) GetUserStats(c *gin.Context) {
+	userID, username, ok := h.resolveUser(c)
+	if !ok {
 		return
 	}
 
 	stats := UserStats{
-			WatchlistStats: make([]WatchlistStatItem, 0),
-			}
-
-	statsQuery := `
-		SELECT status, COUNT(*) 
-		FROM watchlist_items 
-		WHERE user_id = $1 
-		GROUP BY status
-	`
-	rows, err := h.DB.Query(statsQuery, userID)
+		Username:       username,
+		WatchlistStats: make([]WatchlistStatItem, 0),
+	}
+
+	counts, err := h.Watchlist.StatusCounts(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch watchlist stats"})
 		return
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var item WatchlistStatItem
-		if err := rows.Scan(&item.Status, &item.Count); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan stat item"})
-			return
-		}
-		stats.WatchlistStats = append(stats.WatchlistStats, item)
-		stats.TotalEntries += item.Count
+	for _, count := range counts {
+		stats.WatchlistStats = append(stats.WatchlistStats, WatchlistStatItem{Status: count.Status, Count: count.Count})
+		stats.TotalEntries += count.Count
 	}
 
-	scoreQuery := `SELECT COALESCE(AVG(rating), 0), COUNT(*) FROM reviews WHERE user_id = $1`
-	err = h.DB.QueryRow(scoreQuery, userID).Scan(&stats.MeanScore, &stats.ReviewsCount)
+	summary, err := h.Reviews.Summary(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch score stats"})
 		return
 	}
+	stats.MeanScore = summary.MeanScore
+	stats.ReviewsCount = summary.Count
+
+	if viewerID, ok := c.Get("userID"); ok {
+		stats.Viewer = &ProfileViewer{IsSelf: viewerID.(int) == userID}
+	}
 
 	c.JSON(http.StatusOK, stats)
 }
 
 func (h *StatsHandler) GetUserReviews(c *gin.Context) {
-	username := c.Param("username")
-	
-	query := `
-		SELECT r.id, r.media_id, r.media_type, r.media_title, r.media_poster_path, r.rating, r.comment, r.created_at
-		FROM reviews r
-		JOIN users u ON r.user_id = u.id
-		WHERE u.username = $1
-		ORDER BY r.created_at DESC
-		LIMIT 10
-	`
-	rows, err := h.DB.Query(query, username)
+	userID, _, ok := h.resolveUser(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.Reviews.ListByUser(c.Request.Context(), userID, 10)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
 		return
 	}
-	defer rows.Close()
 
 	var reviews []gin.H
-	for rows.Next() {
-		var review struct {
-			ID int
-			MediaID int
-			MediaType string
-			MediaTitle string
-			MediaPosterPath sql.NullString
-			Rating int
-			Comment string
-			CreatedAt string
-		}
-		rows.Scan(&review.ID, &review.MediaID, &review.MediaType, &review.MediaTitle, &review.MediaPosterPath, &review.Rating, &review.Comment, &review.CreatedAt)
+	for _, review := range list {
 		reviews = append(reviews, gin.H{
-			"id": review.ID,
-			"mediaId": review.MediaID,
-			"mediaType": review.MediaType,
-			"mediaTitle": review.MediaTitle,
-			"mediaPosterPath": review.MediaPosterPath.String,
-			"rating": review.Rating,
-			"comment": review.Comment,
-			"createdAt": review.CreatedAt,
+			"id":              review.ID,
+			"mediaId":         review.MediaID,
+			"mediaType":       review.MediaType,
+			"mediaTitle":      review.MediaTitle,
+			"mediaPosterPath": review.MediaPosterPath,
+			"rating":          review.Rating,
+			"comment":         review.Comment,
+			"createdAt":       review.CreatedAt,
 		})
 	}
 
 	c.JSON(http.StatusOK, reviews)
-}
\ No newline at end of file
+}
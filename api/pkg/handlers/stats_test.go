@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/store"
+)
+
+func seedStats(t *testing.T, stores *store.Stores) int {
+	t.Helper()
+	ctx := context.Background()
+	userID := createTestUser(t, stores, "alice")
+	for _, item := range []store.WatchlistItem{
+		{MediaID: 1, MediaType: "movie", Title: "One", Status: "completed"},
+		{MediaID: 2, MediaType: "movie", Title: "Two", Status: "completed"},
+		{MediaID: 3, MediaType: "tv", Title: "Three", Status: "watching"},
+	} {
+		if err := stores.Watchlist.Upsert(ctx, userID, item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, review := range []store.Review{
+		{MediaID: 1, MediaType: "movie", MediaTitle: "One", Rating: 8},
+		{MediaID: 2, MediaType: "movie", MediaTitle: "Two", Rating: 5},
+	} {
+		if err := stores.Reviews.Upsert(ctx, userID, review); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return userID
+}
+
+func TestStatsForUser(t *testing.T) {
+	stores := store.NewMemory()
+	userID := seedStats(t, stores)
+	h := NewStatsHandler(stores.Users, stores.Watchlist, stores.Reviews)
+
+	router := newTestRouter(0)
+	router.GET("/users/:username/stats", h.GetUserStats)
+	rec := serve(t, router, http.MethodGet, "/users/ALICE/stats", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var stats UserStats
+	decode(t, rec, &stats)
+	if stats.Username != "alice" || stats.TotalEntries != 3 || stats.ReviewsCount != 2 || stats.MeanScore != 6.5 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if stats.Viewer != nil {
+		t.Errorf("anonymous request got viewer %+v", stats.Viewer)
+	}
+
+	viewerRouter := newTestRouter(userID)
+	viewerRouter.GET("/users/:username/stats", h.GetUserStats)
+	rec = serve(t, viewerRouter, http.MethodGet, "/users/alice/stats", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &stats)
+	if stats.Viewer == nil || !stats.Viewer.IsSelf {
+		t.Errorf("owner got viewer %+v, want isSelf", stats.Viewer)
+	}
+}
+
+func TestStatsRedirectsRenamedUser(t *testing.T) {
+	stores := store.NewMemory()
+	userID := seedStats(t, stores)
+	if _, err := stores.Users.UpdateProfile(context.Background(), userID, store.ProfileUpdate{Username: "alicia"}); err != nil {
+		t.Fatal(err)
+	}
+	h := NewStatsHandler(stores.Users, stores.Watchlist, stores.Reviews)
+
+	router := newTestRouter(0)
+	router.GET("/users/:username/stats", h.GetUserStats)
+	rec := serve(t, router, http.MethodGet, "/users/alice/stats?x=1", nil)
+	expectStatus(t, rec, http.StatusFound)
+	if location := rec.Header().Get("Location"); location != "/users/alicia/stats?x=1" {
+		t.Errorf("Location = %q", location)
+	}
+
+	expectStatus(t, serve(t, router, http.MethodGet, "/users/nobody/stats", nil), http.StatusNotFound)
+}
+
+func TestStatsListsRecentReviews(t *testing.T) {
+	stores := store.NewMemory()
+	seedStats(t, stores)
+	h := NewStatsHandler(stores.Users, stores.Watchlist, stores.Reviews)
+
+	router := newTestRouter(0)
+	router.GET("/users/:username/reviews", h.GetUserReviews)
+	rec := serve(t, router, http.MethodGet, "/users/alice/reviews", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var reviews []struct {
+		MediaTitle string `json:"mediaTitle"`
+		Rating     int    `json:"rating"`
+	}
+	decode(t, rec, &reviews)
+	if len(reviews) != 2 {
+		t.Fatalf("reviews = %+v", reviews)
+	}
+}
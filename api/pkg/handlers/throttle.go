@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/throttle"
+
+	"github.com/gin-gonic/gin"
+)
+
+type throttleKey struct {
+	policy throttle.Policy
+	key    string
+}
+
+// accountThrottleKey is the per-account login key. Signed-in password
+// re-checks share it, so a stolen session cannot be used to guess the password.
+func accountThrottleKey(userID int) throttleKey {
+	return throttleKey{throttle.LoginAccount, strconv.Itoa(userID)}
+}
+
+func (h *UserHandler) checkThrottle(c *gin.Context, keys ...throttleKey) bool {
+	var wait time.Duration
+	for _, k := range keys {
+		remaining, err := h.Throttle.Check(c.Request.Context(), k.policy, k.key)
+		if err != nil {
+			log.Printf("Failed to check %s throttle: %v", k.policy.Scope, err)
+			continue
+		}
+		if remaining > wait {
+			wait = remaining
+		}
+	}
+
+	if wait == 0 {
+		return false
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many attempts. Please try again later."})
+	return true
+}
+
+func (h *UserHandler) recordAttempt(c *gin.Context, keys ...throttleKey) {
+	ctx := context.WithoutCancel(c.Request.Context())
+	for _, k := range keys {
+		if _, err := h.Throttle.Record(ctx, k.policy, k.key); err != nil {
+			log.Printf("Failed to record %s attempt: %v", k.policy.Scope, err)
+		}
+	}
+}
+
+func (h *UserHandler) resetThrottle(c *gin.Context, keys ...throttleKey) {
+	ctx := context.WithoutCancel(c.Request.Context())
+	for _, k := range keys {
+		if err := h.Throttle.Reset(ctx, k.policy, k.key); err != nil {
+			log.Printf("Failed to reset %s throttle: %v", k.policy.Scope, err)
+		}
+	}
+}
@@ -4,26 +4,32 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 
 	"github.com/gin-gonic/gin"
 )
 
-type TMDBHandler struct{}
+type TMDBHandler struct {
+	APIKey string
+}
 
-func NewTMDBHandler() *TMDBHandler {
-	return &TMDBHandler{}
+func NewTMDBHandler(apiKey string) *TMDBHandler {
+	return &TMDBHandler{APIKey: apiKey}
+}
+
+func requireTMDB(c *gin.Context, apiKey string) bool {
+	if apiKey == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Movie and TV data is not configured"})
+		return false
+	}
+	return true
 }
 
 func (h *TMDBHandler) Proxy(endpoint string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		apiKey := os.Getenv("TMDB_API_KEY")
-		if apiKey == "" {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Server configuration error"})
+		if !requireTMDB(c, h.APIKey) {
 			return
 		}
-
-		tmdbURL := fmt.Sprintf("https://api.themoviedb.org/3/%s?api_key=%s&language=en-US&page=1", endpoint, apiKey)
+		tmdbURL := fmt.Sprintf("https://api.themoviedb.org/3/%s?api_key=%s&language=en-US&page=1", endpoint, h.APIKey)
 		
 		resp, err := http.Get(tmdbURL)
 		if err != nil {
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/audit"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *UserHandler) issueTokens(ctx context.Context, identity auth.Identity) (string, string, error) {
+	accessToken, err := h.Keys.NewAccessToken(identity)
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshToken, err := auth.GenerateToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	query := `
+		INSERT INTO refresh_tokens (user_id, session_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	_, err = h.DB.ExecContext(ctx, query, identity.UserID, identity.SessionID, auth.HashToken(refreshToken), time.Now().Add(auth.RefreshTokenTTL))
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+func (h *UserHandler) startSession(c *gin.Context, userID int) (gin.H, error) {
+	sessionID, err := h.createSession(c, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := h.Users.Identity(c.Request.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+	identity.SessionID = sessionID
+
+	accessToken, refreshToken, err := h.issueTokens(c.Request.Context(), *identity)
+	if err != nil {
+		return nil, err
+	}
+
+	return gin.H{
+		"token":        accessToken,
+		"refreshToken": refreshToken,
+		"expiresIn":    int(auth.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+func (h *UserHandler) Refresh(c *gin.Context) {
+	var payload models.RefreshPayload
+	if err := c.ShouldBindJSON(&payload); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	refreshTokenValue, useCookies := payload.RefreshToken, false
+	if refreshTokenValue == "" {
+		cookie, _ := c.Cookie(auth.RefreshCookieName)
+		if cookie == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Refresh token required"})
+			return
+		}
+		if !validCSRFToken(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid CSRF token"})
+			return
+		}
+		refreshTokenValue, useCookies = cookie, true
+	}
+
+	var tokenID, userID int
+	var sessionID string
+	var expiresAt time.Time
+	var revokedAt sql.NullTime
+	query := `SELECT id, user_id, session_id, expires_at, revoked_at FROM refresh_tokens WHERE token_hash = $1`
+	err := h.DB.QueryRowContext(c.Request.Context(), query, auth.HashToken(refreshTokenValue)).Scan(&tokenID, &userID, &sessionID, &expiresAt, &revokedAt)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	if revokedAt.Valid {
+		h.revokeSession(context.WithoutCancel(c.Request.Context()), userID, sessionID)
+		h.audit(c, audit.EventRefreshTokenReuse, userID, map[string]interface{}{"sessionId": sessionID})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	if time.Now().After(expiresAt) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token expired"})
+		return
+	}
+
+	res, err := h.DB.ExecContext(c.Request.Context(), `UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL`, tokenID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate refresh token"})
+		return
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		h.revokeSession(context.WithoutCancel(c.Request.Context()), userID, sessionID)
+		h.audit(c, audit.EventRefreshTokenReuse, userID, map[string]interface{}{"sessionId": sessionID})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	identity := auth.Identity{SessionID: sessionID}
+	query = `
+		SELECT u.id, u.username, u.profile_picture_url, u.role, u.token_version
+		FROM users u
+		JOIN sessions s ON s.user_id = u.id
+		WHERE u.id = $1 AND s.id = $2 AND s.revoked_at IS NULL
+	`
+	err = h.DB.QueryRowContext(c.Request.Context(), query, userID, sessionID).Scan(&identity.UserID, &identity.Username, &identity.Picture, &identity.Role, &identity.TokenVersion)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	accessToken, refreshToken, err := h.issueTokens(c.Request.Context(), identity)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+
+	h.DB.ExecContext(c.Request.Context(), `UPDATE sessions SET last_seen_at = CURRENT_TIMESTAMP, ip_address = $2 WHERE id = $1`, sessionID, c.ClientIP())
+
+	response, err := h.deliverSession(c, gin.H{
+		"token":        accessToken,
+		"refreshToken": refreshToken,
+		"expiresIn":    int(auth.AccessTokenTTL.Seconds()),
+	}, useCookies)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *UserHandler) Logout(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	tokenID, _ := c.Get("tokenID")
+	tokenExpiresAt, _ := c.Get("tokenExpiresAt")
+
+	_, err := h.DB.ExecContext(c.Request.Context(), `INSERT INTO revoked_tokens (jti, user_id, expires_at) VALUES ($1, $2, $3) ON CONFLICT (jti) DO NOTHING`, tokenID, userID, tokenExpiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+		return
+	}
+
+	if err := h.revokeSession(c.Request.Context(), userID.(int), c.GetString("sessionID")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	h.DB.ExecContext(c.Request.Context(), `DELETE FROM revoked_tokens WHERE expires_at < CURRENT_TIMESTAMP`)
+
+	h.audit(c, audit.EventLogout, userID, map[string]interface{}{"sessionId": c.GetString("sessionID")})
+	h.clearSessionCookies(c)
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
@@ -1,28 +1,39 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+	"strconv"
 	"sync"
 
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/middleware"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/store"
 	"github.com/gin-gonic/gin"
 )
 
 type TvHandler struct {
-	DB *sql.DB
+	APIKey    string
+	Reviews   store.ReviewStore
+	Watchlist store.WatchlistStore
 }
 
-func NewTvHandler(db *sql.DB) *TvHandler {
-	return &TvHandler{DB: db}
+func NewTvHandler(apiKey string, reviews store.ReviewStore, watchlist store.WatchlistStore) *TvHandler {
+	return &TvHandler{APIKey: apiKey, Reviews: reviews, Watchlist: watchlist}
 }
 
 func (h *TvHandler) GetTvDetails(c *gin.Context) {
 	tvID := c.Param("id")
-	apiKey := os.Getenv("TMDB_API_KEY")
+	mediaID, err := strconv.Atoi(tvID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid TV show ID"})
+		return
+	}
+	if !requireTMDB(c, h.APIKey) {
+		return
+	}
+	apiKey := h.APIKey
 
 	urls := map[string]string{
 		"details":         fmt.Sprintf("https://api.themoviedb.org/3/tv/%s?api_key=%s", tvID, apiKey),
@@ -55,42 +66,16 @@ func (h *TvHandler) GetTvDetails(c *gin.Context) {
 	}
 	wg.Wait()
 
-	reviewQuery := `
-		SELECT r.id, r.rating, r.comment, r.created_at, u.username, u.profile_picture_url
-		FROM reviews r
-		JOIN users u ON r.user_id = u.id
-		WHERE r.media_id = $1 AND r.media_type = 'tv'
-		ORDER BY r.created_at DESC
-	`
-	rows, err := h.DB.Query(reviewQuery, tvID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+	if c.GetBool(middleware.DatabaseUnavailableKey) {
+		results["reviews"] = json.RawMessage("null")
+		c.JSON(http.StatusOK, results)
 		return
 	}
-	defer rows.Close()
 
-	var reviews []gin.H
-	for rows.Next() {
-		var review struct {
-			ID                int
-			Rating            int
-			Comment           string
-			CreatedAt         string
-			Username          string
-			ProfilePictureURL sql.NullString
-		}
-		if err := rows.Scan(&review.ID, &review.Rating, &review.Comment, &review.CreatedAt, &review.Username, &review.ProfilePictureURL); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan review"})
-			return
-		}
-		reviews = append(reviews, gin.H{
-			"id":                review.ID,
-			"rating":            review.Rating,
-			"comment":           review.Comment,
-			"createdAt":         review.CreatedAt,
-			"username":          review.Username,
-			"profilePictureUrl": review.ProfilePictureURL.String,
-		})
+	reviews, err := mediaReviews(c.Request.Context(), h.Reviews, mediaID, "tv")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+		return
 	}
 
 	results["reviews"] = func() json.RawMessage {
@@ -98,5 +83,13 @@ func (h *TvHandler) GetTvDetails(c *gin.Context) {
 		return json.RawMessage(b)
 	}()
 
+	if userID, ok := c.Get("userID"); ok {
+		viewer, err := viewerMediaState(c.Request.Context(), h.Reviews, h.Watchlist, userID.(int), mediaID, "tv")
+		if err == nil {
+			b, _ := json.Marshal(viewer)
+			results["viewer"] = json.RawMessage(b)
+		}
+	}
+
 	c.JSON(http.StatusOK, results)
 }
\ No newline at end of file
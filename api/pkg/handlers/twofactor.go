@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/audit"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/models"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/throttle"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	mfaChallengeTTL         = 5 * time.Minute
+	mfaChallengeMaxAttempts = 5
+	recoveryCodeCount       = 10
+	totpIssuer              = "CineLume"
+)
+
+var errAccountDisabled = errors.New("This account has been disabled. Contact support for help")
+
+func (h *UserHandler) completeLogin(c *gin.Context, userID int) (gin.H, error) {
+	user, err := h.Users.Get(c.Request.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DisabledAt != nil {
+		return nil, errAccountDisabled
+	}
+	if !user.TwoFactorEnabled {
+		return h.startSession(c, userID)
+	}
+
+	challenge, err := auth.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO mfa_challenges (token_hash, user_id, expires_at) VALUES ($1, $2, $3)`
+	_, err = h.DB.ExecContext(c.Request.Context(), query, auth.HashToken(challenge), userID, time.Now().Add(mfaChallengeTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	h.DB.ExecContext(c.Request.Context(), `DELETE FROM mfa_challenges WHERE expires_at < CURRENT_TIMESTAMP`)
+
+	return gin.H{
+		"mfaRequired":    true,
+		"challengeToken": challenge,
+		"expiresIn":      int(mfaChallengeTTL.Seconds()),
+	}, nil
+}
+
+func (h *UserHandler) verifySecondFactor(ctx context.Context, userID int, code, recoveryCode string) (bool, error) {
+	if code != "" {
+		var secret sql.NullString
+		var lastStep int64
+		query := `SELECT totp_secret, totp_last_step FROM users WHERE id = $1 AND totp_enabled_at IS NOT NULL`
+		err := h.DB.QueryRowContext(ctx, query, userID).Scan(&secret, &lastStep)
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		step, ok := auth.ValidateTOTP(secret.String, code, time.Now(), lastStep)
+		if !ok {
+			return false, nil
+		}
+
+		res, err := h.DB.ExecContext(ctx, `UPDATE users SET totp_last_step = $2 WHERE id = $1 AND totp_last_step < $2`, userID, step)
+		if err != nil {
+			return false, err
+		}
+		rowsAffected, _ := res.RowsAffected()
+		return rowsAffected == 1, nil
+	}
+
+	if recoveryCode != "" {
+		query := `
+			UPDATE mfa_recovery_codes SET used_at = CURRENT_TIMESTAMP
+			WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+		`
+		res, err := h.DB.ExecContext(ctx, query, userID, auth.HashToken(auth.NormalizeRecoveryCode(recoveryCode)))
+		if err != nil {
+			return false, err
+		}
+		rowsAffected, _ := res.RowsAffected()
+		return rowsAffected == 1, nil
+	}
+
+	return false, nil
+}
+
+func (h *UserHandler) reauthenticate(ctx context.Context, userID int, payload models.TwoFactorReauthPayload) (bool, error) {
+	passwordHash, err := h.Users.PasswordHash(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if passwordHash != "" && !h.checkPassword(ctx, userID, passwordHash, payload.Password) {
+		return false, nil
+	}
+	return h.verifySecondFactor(ctx, userID, payload.Code, payload.RecoveryCode)
+}
+
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = auth.HashToken(code)
+	}
+	return codes, hashes, nil
+}
+
+func (h *UserHandler) LoginSecondFactor(c *gin.Context) {
+	var payload models.TwoFactorLoginPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var userID int
+	query := `
+		UPDATE mfa_challenges SET attempts = attempts + 1
+		WHERE token_hash = $1 AND expires_at > CURRENT_TIMESTAMP AND attempts < $2
+		RETURNING user_id
+	`
+	err := h.DB.QueryRowContext(c.Request.Context(), query, auth.HashToken(payload.ChallengeToken), mfaChallengeMaxAttempts).Scan(&userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge"})
+		return
+	}
+
+	factorKey := throttleKey{throttle.SecondFactor, strconv.Itoa(userID)}
+	if h.checkThrottle(c, factorKey) {
+		return
+	}
+
+	ok, err := h.verifySecondFactor(c.Request.Context(), userID, payload.Code, payload.RecoveryCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		return
+	}
+	if !ok {
+		h.recordAttempt(c, factorKey)
+		h.audit(c, audit.EventLoginFailed, userID, map[string]interface{}{"reason": "invalid_second_factor"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid verification code"})
+		return
+	}
+
+	h.resetThrottle(c, factorKey)
+
+	h.DB.ExecContext(c.Request.Context(), `DELETE FROM mfa_challenges WHERE token_hash = $1`, auth.HashToken(payload.ChallengeToken))
+
+	response, err := h.startSession(c, userID)
+	if err == nil {
+		method := "totp"
+		if payload.Code == "" {
+			method = "recovery_code"
+		}
+		h.auditLogin(c, userID, method, response)
+		response, err = h.deliverSession(c, response, wantsCookieSession(c))
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *UserHandler) SetupTwoFactor(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	user, err := h.Users.Get(c.Request.Context(), userID.(int))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.TwoFactorEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+		return
+	}
+
+	if err := h.Users.SetTOTPSecret(c.Request.Context(), user.ID, secret); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start two-factor setup"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":     secret,
+		"otpauthUri": auth.TOTPURI(totpIssuer, user.Username, secret),
+	})
+}
+
+func (h *UserHandler) EnableTwoFactor(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var payload models.TwoFactorCodePayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.Users.Get(c.Request.Context(), userID.(int))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.TwoFactorEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+	secret, err := h.Users.TOTPSecret(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+	if secret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Start two-factor setup first"})
+		return
+	}
+
+	step, ok := auth.ValidateTOTP(secret, payload.Code, time.Now(), 0)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verification code"})
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+		return
+	}
+	enabled, err := h.Users.EnableTOTP(c.Request.Context(), user.ID, step, hashes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+	if !enabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	h.audit(c, audit.EventTwoFactorEnabled, userID, nil)
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Two-factor authentication enabled",
+		"recoveryCodes": codes,
+	})
+}
+
+func (h *UserHandler) DisableTwoFactor(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var payload models.TwoFactorReauthPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accountKey := accountThrottleKey(userID.(int))
+	if h.checkThrottle(c, accountKey) {
+		return
+	}
+	ok, err := h.reauthenticate(c.Request.Context(), userID.(int), payload)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify credentials"})
+		return
+	}
+	if !ok {
+		h.recordAttempt(c, accountKey)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password or verification code"})
+		return
+	}
+	h.resetThrottle(c, accountKey)
+
+	if err := h.Users.DisableTOTP(c.Request.Context(), userID.(int)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+
+	h.audit(c, audit.EventTwoFactorDisabled, userID, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+func (h *UserHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var payload models.TwoFactorReauthPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accountKey := accountThrottleKey(userID.(int))
+	if h.checkThrottle(c, accountKey) {
+		return
+	}
+	ok, err := h.reauthenticate(c.Request.Context(), userID.(int), payload)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify credentials"})
+		return
+	}
+	if !ok {
+		h.recordAttempt(c, accountKey)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password or verification code"})
+		return
+	}
+	h.resetThrottle(c, accountKey)
+
+	codes, hashes, err := newRecoveryCodes()
+	if err == nil {
+		err = h.Users.ReplaceRecoveryCodes(c.Request.Context(), userID.(int), hashes)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+		return
+	}
+
+	h.audit(c, audit.EventRecoveryCodesRegenerated, userID, nil)
+	c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
@@ -2,50 +2,96 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
+	"log"
 	"net/http"
 	"net/url"
-	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/audit"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/config"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/mailer"
 	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/models"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/passwords"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/store"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/throttle"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/usernames"
 
 	"github.com/cloudinary/cloudinary-go/v2/api"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type UserHandler struct {
-	DB *sql.DB
+	Config *config.Config
+	// DB holds session, refresh token, MFA, verification and API token
+	// state; account data goes through Users.
+	DB        *sql.DB
+	Users     store.UserStore
+	Keys      *auth.KeySet
+	Mailer    mailer.Mailer
+	Throttle  *throttle.Throttle
+	Passwords *passwords.Manager
+	Audit     *audit.Logger
 }
 
-func NewUserHandler(db *sql.DB) *UserHandler {
-	return &UserHandler{DB: db}
+func NewUserHandler(cfg *config.Config, db *sql.DB, users store.UserStore, keys *auth.KeySet, m mailer.Mailer) *UserHandler {
+	return &UserHandler{Config: cfg, DB: db, Users: users, Keys: keys, Mailer: m, Throttle: throttle.New(db), Passwords: passwords.Named(cfg.PasswordHasher, cfg.Argon2), Audit: audit.New(db)}
 }
 
 func (h *UserHandler) Register(c *gin.Context) {
+	registerKey := throttleKey{throttle.RegisterIP, c.ClientIP()}
+	if h.checkThrottle(c, registerKey) {
+		return
+	}
+	h.recordAttempt(c, registerKey)
+
 	var payload models.RegisterPayload
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(payload.Password), bcrypt.DefaultCost)
+	payload.Username = strings.TrimSpace(payload.Username)
+	payload.Email = normalizeEmail(payload.Email)
+	if err := usernames.Validate(payload.Username); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	taken, err := h.Users.UsernameTaken(c.Request.Context(), payload.Username, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create user"})
+		return
+	}
+	if taken {
+		c.JSON(http.StatusConflict, gin.H{"error": store.ErrUsernameTaken.Error()})
+		return
+	}
+
+	hashedPassword, err := h.Passwords.Hash(payload.Password)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
 		return
 	}
 
-	query := `INSERT INTO users (username, email, password_hash) VALUES ($1, $2, $3) RETURNING id, created_at`
-	var userID int
-	var createdAt time.Time
-	err = h.DB.QueryRow(query, payload.Username, payload.Email, string(hashedPassword)).Scan(&userID, &createdAt)
+	userID, err := h.Users.Create(c.Request.Context(), payload.Username, payload.Email, hashedPassword)
+	if errors.Is(err, store.ErrUsernameTaken) || errors.Is(err, store.ErrEmailTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create user"})
 		return
 	}
 
+	if err := h.sendEmailVerification(c, userID, payload.Email); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", userID, err)
+	}
+
+	h.audit(c, audit.EventRegistered, userID, nil)
 	c.JSON(http.StatusCreated, gin.H{"message": "User created successfully", "userID": userID})
 }
 
@@ -56,34 +102,65 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	query := `SELECT id, username, email, password_hash, profile_picture_url FROM users WHERE email = $1`
-	err := h.DB.QueryRow(query, payload.Email).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.ProfilePictureURL)
+	identifier := payload.Identifier
+	if identifier == "" {
+		identifier = payload.Email
+	}
+	identifier = normalizeEmail(identifier)
+
+	ipKey := throttleKey{throttle.LoginIP, c.ClientIP()}
+	if h.checkThrottle(c, ipKey) {
+		return
+	}
+
+	creds, err := h.Users.FindCredentials(c.Request.Context(), identifier)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify credentials"})
+		return
+	}
+
+	accountKey := throttleKey{throttle.LoginAccount, identifier}
+	if err == nil {
+		accountKey = accountThrottleKey(creds.UserID)
+	}
+	if h.checkThrottle(c, accountKey) {
+		return
+	}
+
 	if err != nil {
+		h.Passwords.SimulateVerify(payload.Password)
+		h.recordAttempt(c, ipKey, accountKey)
+		h.audit(c, audit.EventLoginFailed, nil, map[string]interface{}{"reason": "unknown_account", "identifier": identifier})
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(payload.Password))
-	if err != nil {
+	userID := creds.UserID
+	if !h.checkPassword(c.Request.Context(), userID, creds.PasswordHash, payload.Password) {
+		h.recordAttempt(c, ipKey, accountKey)
+		h.audit(c, audit.EventLoginFailed, userID, map[string]interface{}{"reason": "invalid_password"})
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":      user.ID,
-		"username": user.Username,
-		"pfp":      user.ProfilePictureURL,
-		"exp":      time.Now().Add(time.Hour * 24 * 7).Unix(),
-	})
+	h.resetThrottle(c, accountKey)
 
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	response, err := h.completeLogin(c, userID)
+	if err == nil {
+		h.auditLogin(c, userID, "password", response)
+		response, err = h.deliverSession(c, response, wantsCookieSession(c))
+	}
+	if errors.Is(err, errAccountDisabled) {
+		h.audit(c, audit.EventLoginFailed, userID, map[string]interface{}{"reason": "account_disabled"})
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": tokenString})
+	c.JSON(http.StatusOK, response)
 }
 
 func (h *UserHandler) UpdateProfile(c *gin.Context) {
@@ -99,34 +176,69 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	query := `
-		UPDATE users 
-		SET username = $1, email = $2, description = $3, profile_picture_url = $4
-		WHERE id = $5
-	`
-	_, err := h.DB.Exec(query, payload.Username, payload.Email, payload.Description, payload.ProfilePictureURL, userID)
+	payload.Username = strings.TrimSpace(payload.Username)
+	payload.Email = normalizeEmail(payload.Email)
+
+	current, err := h.Users.Get(c.Request.Context(), userID.(int))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
 		return
 	}
+	if payload.Username != current.Username {
+		if err := usernames.Validate(payload.Username); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":      userID,
-		"username": payload.Username,
-		"pfp":      payload.ProfilePictureURL,
-		"exp":      time.Now().Add(time.Hour * 24 * 7).Unix(),
+	change, err := h.Users.UpdateProfile(c.Request.Context(), userID.(int), store.ProfileUpdate{
+		Username:          payload.Username,
+		Description:       payload.Description,
+		ProfilePictureURL: payload.ProfilePictureURL,
 	})
+	if errors.Is(err, store.ErrUsernameTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		return
+	}
+
+	var pendingEmail string
+	if payload.Email != normalizeEmail(change.Email) {
+		if err := h.sendEmailVerification(c, userID.(int), payload.Email); err != nil {
+			log.Printf("Failed to send verification email to user %v: %v", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+			return
+		}
+		pendingEmail = payload.Email
+		h.audit(c, audit.EventEmailChangeRequested, userID, map[string]interface{}{"email": payload.Email})
+	}
+	if change.Renamed {
+		h.audit(c, audit.EventUsernameChanged, userID, map[string]interface{}{"from": change.PreviousUsername, "to": payload.Username})
+	}
 
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	tokenString, err := h.Keys.NewAccessToken(auth.Identity{
+		UserID:       userID.(int),
+		Username:     payload.Username,
+		Picture:      payload.ProfilePictureURL,
+		Role:         c.GetString("role"),
+		SessionID:    c.GetString("sessionID"),
+		TokenVersion: c.GetInt("tokenVersion"),
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create new token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Profile updated successfully",
-		"token":   tokenString,
-	})
+	response := gin.H{"message": "Profile updated successfully"}
+	h.attachAccessToken(c, response, tokenString)
+	if pendingEmail != "" {
+		response["pendingEmail"] = pendingEmail
+		response["message"] = "Profile updated successfully. Check your new email address to confirm the change."
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 func (h *UserHandler) GetProfile(c *gin.Context) {
@@ -136,9 +248,7 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	query := `SELECT id, username, email, profile_picture_url, description, created_at FROM users WHERE id = $1`
-	err := h.DB.QueryRow(query, userID).Scan(&user.ID, &user.Username, &user.Email, &user.ProfilePictureURL, &user.Description, &user.CreatedAt)
+	user, err := h.Users.Get(c.Request.Context(), userID.(int))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
@@ -146,6 +256,10 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+// UpdatePassword always signs out the caller's other sessions. The earlier
+// signOutOtherSessions flag is gone because Refresh re-reads token_version, so
+// any refresh token left alive would keep minting access tokens after the
+// password changed. Only the current session survives.
 func (h *UserHandler) UpdatePassword(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -159,46 +273,61 @@ func (h *UserHandler) UpdatePassword(c *gin.Context) {
 		return
 	}
 
-	var currentPasswordHash string
-	query := `SELECT password_hash FROM users WHERE id = $1`
-	err := h.DB.QueryRow(query, userID).Scan(&currentPasswordHash)
+	accountKey := accountThrottleKey(userID.(int))
+	if h.checkThrottle(c, accountKey) {
+		return
+	}
+
+	currentPasswordHash, err := h.Users.PasswordHash(c.Request.Context(), userID.(int))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "User not found"})
 		return
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(currentPasswordHash), []byte(payload.CurrentPassword))
-	if err != nil {
+	if !h.checkPassword(c.Request.Context(), userID.(int), currentPasswordHash, payload.CurrentPassword) {
+		h.recordAttempt(c, accountKey)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid current password"})
 		return
 	}
+	h.resetThrottle(c, accountKey)
 
-	newHashedPassword, err := bcrypt.GenerateFromPassword([]byte(payload.NewPassword), bcrypt.DefaultCost)
+	newHashedPassword, err := h.Passwords.Hash(payload.NewPassword)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash new password"})
 		return
 	}
 
-	updateQuery := `UPDATE users SET password_hash = $1 WHERE id = $2`
-	_, err = h.DB.Exec(updateQuery, string(newHashedPassword), userID)
+	identity, revoked, err := h.Users.ChangePassword(c.Request.Context(), userID.(int), newHashedPassword, c.GetString("sessionID"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
 		return
 	}
+	h.audit(c, audit.EventPasswordChanged, identity.UserID, map[string]interface{}{"revokedSessions": revoked})
+
+	tokenString, err := h.Keys.NewAccessToken(*identity)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create new token"})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Password updated successfully"})
+	response := gin.H{"message": "Password updated successfully"}
+	h.attachAccessToken(c, response, tokenString)
+	c.JSON(http.StatusOK, response)
 }
 
 func (h *UserHandler) GetUploadSignature(c *gin.Context) {
-	apiKey := os.Getenv("CLOUDINARY_API_KEY")
-	apiSecret := os.Getenv("CLOUDINARY_API_SECRET")
+	cloudinary := h.Config.Cloudinary
+	if !cloudinary.Enabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Image uploads are not configured"})
+		return
+	}
 
 	timestamp := time.Now().Unix()
 	
 	params := url.Values{}
 	params.Set("timestamp", strconv.FormatInt(timestamp, 10))
 
-	signature, err := api.SignParameters(params, apiSecret)
+	signature, err := api.SignParameters(params, cloudinary.APISecret)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign params"})
 		return
@@ -207,6 +336,10 @@ func (h *UserHandler) GetUploadSignature(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"signature": signature,
 		"timestamp": timestamp,
-		"apiKey":    apiKey,
+		"apiKey":    cloudinary.APIKey,
 	})
-}
\ No newline at end of file
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/audit"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/mailer"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const emailVerificationTTL = 24 * time.Hour
+
+var errAppURLNotConfigured = errors.New("APP_URL is not configured, refusing to email a link built from the request host")
+
+func (h *UserHandler) appURL(c *gin.Context, path string, params url.Values) string {
+	base := h.Config.AppURL
+	if base == "" {
+		scheme := "https"
+		if c.Request.TLS == nil && c.GetHeader("X-Forwarded-Proto") != "https" {
+			scheme = "http"
+		}
+		base = scheme + "://" + c.Request.Host
+	}
+	if len(params) == 0 {
+		return base + path
+	}
+	return base + path + "?" + params.Encode()
+}
+
+func (h *UserHandler) emailLink(path string, params url.Values) (string, error) {
+	if h.Config.AppURL == "" {
+		return "", errAppURLNotConfigured
+	}
+	if len(params) == 0 {
+		return h.Config.AppURL + path, nil
+	}
+	return h.Config.AppURL + path + "?" + params.Encode(), nil
+}
+
+func (h *UserHandler) sendEmailVerification(c *gin.Context, userID int, email string) error {
+	if h.Config.AppURL == "" {
+		return errAppURLNotConfigured
+	}
+
+	token, err := auth.GenerateToken(32)
+	if err != nil {
+		return err
+	}
+
+	tx, err := h.DB.BeginTx(c.Request.Context(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(c.Request.Context(), `UPDATE email_verification_tokens SET used_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND used_at IS NULL`, userID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO email_verification_tokens (user_id, email, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	_, err = tx.ExecContext(c.Request.Context(), query, userID, email, auth.HashToken(token), time.Now().Add(emailVerificationTTL))
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	link, err := h.emailLink("/verify-email", url.Values{"token": {token}})
+	if err != nil {
+		return err
+	}
+	return h.Mailer.Send(mailer.Message{
+		To:      email,
+		Subject: "Confirm your CineLume email address",
+		Body: fmt.Sprintf("Hi,\n\nPlease confirm this email address for your CineLume account by opening the link below:\n\n%s\n\nThe link expires in %d hours. If you didn't request this, you can ignore this email.\n",
+			link, int(emailVerificationTTL.Hours())),
+	})
+}
+
+func (h *UserHandler) VerifyEmail(c *gin.Context) {
+	var payload models.VerifyEmailPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := h.DB.BeginTx(c.Request.Context(), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+	defer tx.Rollback()
+
+	var tokenID, userID int
+	var email string
+	query := `
+		UPDATE email_verification_tokens SET used_at = CURRENT_TIMESTAMP
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		RETURNING id, user_id, email
+	`
+	err = tx.QueryRowContext(c.Request.Context(), query, auth.HashToken(payload.Token)).Scan(&tokenID, &userID, &email)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	var taken bool
+	err = tx.QueryRowContext(c.Request.Context(), `SELECT EXISTS(SELECT 1 FROM users WHERE LOWER(email) = LOWER($1) AND id <> $2)`, email, userID).Scan(&taken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+	if taken {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email address is already in use"})
+		return
+	}
+
+	_, err = tx.ExecContext(c.Request.Context(), `UPDATE users SET email = $1, email_verified_at = CURRENT_TIMESTAMP WHERE id = $2`, email, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	h.audit(c, audit.EventEmailVerified, userID, map[string]interface{}{"email": email})
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully", "email": email})
+}
+
+func (h *UserHandler) ResendEmailVerification(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var email string
+	var verified bool
+	err := h.DB.QueryRowContext(c.Request.Context(), `SELECT email, email_verified_at IS NOT NULL FROM users WHERE id = $1`, userID).Scan(&email, &verified)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if verified {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is already verified"})
+		return
+	}
+
+	if err := h.sendEmailVerification(c, userID.(int), email); err != nil {
+		log.Printf("Failed to send verification email to user %v: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
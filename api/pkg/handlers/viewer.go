@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/store"
+	"github.com/gin-gonic/gin"
+)
+
+type MediaViewerState struct {
+	Rating          *int    `json:"rating"`
+	ReviewID        *int    `json:"reviewId"`
+	InWatchlist     bool    `json:"inWatchlist"`
+	WatchlistStatus *string `json:"watchlistStatus"`
+}
+
+func mediaReviews(ctx context.Context, reviews store.ReviewStore, mediaID int, mediaType string) ([]gin.H, error) {
+	list, err := reviews.ListForMedia(ctx, mediaID, mediaType)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []gin.H
+	for _, review := range list {
+		result = append(result, gin.H{
+			"id":                review.ID,
+			"rating":            review.Rating,
+			"comment":           review.Comment,
+			"createdAt":         review.CreatedAt,
+			"username":          review.Username,
+			"profilePictureUrl": review.ProfilePictureURL,
+		})
+	}
+	return result, nil
+}
+
+func viewerMediaState(ctx context.Context, reviews store.ReviewStore, watchlist store.WatchlistStore, userID, mediaID int, mediaType string) (*MediaViewerState, error) {
+	state := &MediaViewerState{}
+
+	review, err := reviews.FindByUserMedia(ctx, userID, mediaID, mediaType)
+	if err == nil {
+		state.ReviewID = &review.ID
+		state.Rating = &review.Rating
+	} else if !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+
+	status, err := watchlist.Status(ctx, userID, mediaID, mediaType)
+	if err == nil {
+		state.WatchlistStatus = &status
+	} else if !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+
+	state.InWatchlist = state.WatchlistStatus != nil
+	return state, nil
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/store"
+)
+
+func TestWatchlistAddListRemove(t *testing.T) {
+	stores := store.NewMemory()
+	userID := createTestUser(t, stores, "alice")
+	h := NewWatchlistHandler(stores.Watchlist)
+
+	router := newTestRouter(userID)
+	router.POST("/watchlist", h.AddItem)
+	router.GET("/watchlist", h.GetWatchlist)
+	router.DELETE("/watchlist/:id", h.RemoveItem)
+
+	rec := serve(t, router, http.MethodPost, "/watchlist", WatchlistItemPayload{MediaID: 42, MediaType: "movie", Title: "Film", Status: "watching"})
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = serve(t, router, http.MethodGet, "/watchlist", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var items []store.WatchlistItem
+	decode(t, rec, &items)
+	if len(items) != 1 || items[0].MediaID != 42 || items[0].Status != "watching" {
+		t.Fatalf("watchlist = %+v", items)
+	}
+
+	expectStatus(t, serve(t, router, http.MethodDelete, "/watchlist/42", nil), http.StatusOK)
+	expectStatus(t, serve(t, router, http.MethodDelete, "/watchlist/42", nil), http.StatusNotFound)
+	expectStatus(t, serve(t, router, http.MethodDelete, "/watchlist/abc", nil), http.StatusBadRequest)
+}
+
+func TestWatchlistRejectsIncompletePayload(t *testing.T) {
+	stores := store.NewMemory()
+	h := NewWatchlistHandler(stores.Watchlist)
+	router := newTestRouter(createTestUser(t, stores, "alice"))
+	router.POST("/watchlist", h.AddItem)
+
+	rec := serve(t, router, http.MethodPost, "/watchlist", map[string]interface{}{"mediaId": 1})
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestWatchlistIsPerUser(t *testing.T) {
+	stores := store.NewMemory()
+	h := NewWatchlistHandler(stores.Watchlist)
+	alice := createTestUser(t, stores, "alice")
+	bob := createTestUser(t, stores, "bob")
+
+	aliceRouter := newTestRouter(alice)
+	aliceRouter.POST("/watchlist", h.AddItem)
+	expectStatus(t, serve(t, aliceRouter, http.MethodPost, "/watchlist", WatchlistItemPayload{MediaID: 1, MediaType: "tv", Title: "Show", Status: "completed"}), http.StatusOK)
+
+	bobRouter := newTestRouter(bob)
+	bobRouter.GET("/watchlist", h.GetWatchlist)
+	bobRouter.DELETE("/watchlist/:id", h.RemoveItem)
+
+	rec := serve(t, bobRouter, http.MethodGet, "/watchlist", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if body := rec.Body.String(); body != "null" && body != "[]" {
+		t.Fatalf("bob sees alice's watchlist: %s", body)
+	}
+	expectStatus(t, serve(t, bobRouter, http.MethodDelete, "/watchlist/1", nil), http.StatusNotFound)
+}
@@ -0,0 +1,20 @@
+package mailer
+
+import "github.com/DarrenAnthonyBeltham/cinelume/api/pkg/config"
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(msg Message) error
+}
+
+func New(cfg config.Mail) Mailer {
+	if cfg.SMTPHost == "" {
+		return NewLogMailer(cfg.LogFile)
+	}
+	return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From)
+}
@@ -0,0 +1,84 @@
+package maintenance
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type Result struct {
+	Table string
+	Rows  int64
+}
+
+var expiredRows = []struct {
+	table string
+	query string
+}{
+	{"revoked_tokens", `DELETE FROM revoked_tokens WHERE expires_at < CURRENT_TIMESTAMP`},
+	{"refresh_tokens", `DELETE FROM refresh_tokens WHERE expires_at < CURRENT_TIMESTAMP`},
+	{"oauth_states", `DELETE FROM oauth_states WHERE expires_at < CURRENT_TIMESTAMP`},
+	{"mfa_challenges", `DELETE FROM mfa_challenges WHERE expires_at < CURRENT_TIMESTAMP`},
+	{"email_verification_tokens", `DELETE FROM email_verification_tokens WHERE expires_at < CURRENT_TIMESTAMP`},
+	{"password_reset_tokens", `DELETE FROM password_reset_tokens WHERE expires_at < CURRENT_TIMESTAMP`},
+	{"auth_throttles", `
+		DELETE FROM auth_throttles
+		WHERE last_failure_at < CURRENT_TIMESTAMP - INTERVAL '24 hours'
+			AND (locked_until IS NULL OR locked_until < CURRENT_TIMESTAMP)
+	`},
+}
+
+var statsTables = []string{
+	"users",
+	"username_history",
+	"watchlist_items",
+	"reviews",
+	"sessions",
+	"refresh_tokens",
+	"api_tokens",
+	"audit_events",
+}
+
+var searchTables = []string{
+	"users",
+	"username_history",
+	"watchlist_items",
+	"reviews",
+}
+
+func PurgeExpired(ctx context.Context, db *sql.DB) ([]Result, error) {
+	var results []Result
+	for _, e := range expiredRows {
+		res, err := db.ExecContext(ctx, e.query)
+		if err != nil {
+			return results, fmt.Errorf("purging %s: %w", e.table, err)
+		}
+		rows, _ := res.RowsAffected()
+		results = append(results, Result{Table: e.table, Rows: rows})
+	}
+	return results, nil
+}
+
+func Reindex(ctx context.Context, db *sql.DB) error {
+	for _, table := range searchTables {
+		if _, err := db.ExecContext(ctx, `REINDEX TABLE CONCURRENTLY `+table); err != nil {
+			return fmt.Errorf("reindexing %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+func Analyze(ctx context.Context, db *sql.DB) ([]Result, error) {
+	var results []Result
+	for _, table := range statsTables {
+		if _, err := db.ExecContext(ctx, `ANALYZE `+table); err != nil {
+			return results, fmt.Errorf("analyzing %s: %w", table, err)
+		}
+		var rows int64
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+table).Scan(&rows); err != nil {
+			return results, err
+		}
+		results = append(results, Result{Table: table, Rows: rows})
+	}
+	return results, nil
+}
@@ -1,53 +1,188 @@
 package middleware
 
 import (
-	"fmt"
+	"database/sql"
 	"net/http"
-	"os"
 	"strings"
 
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(db *sql.DB, keys *auth.KeySet) gin.HandlerFunc {
+	return authMiddleware(db, keys, false)
+}
+
+func APITokenMiddleware(db *sql.DB, keys *auth.KeySet) gin.HandlerFunc {
+	return authMiddleware(db, keys, true)
+}
+
+func OptionalAuth(db *sql.DB, keys *auth.KeySet) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			return
+		c.Writer.Header().Add("Vary", "Authorization, Cookie")
+		tokenString, _, err := requestToken(c)
+		if err == nil && !strings.HasPrefix(tokenString, auth.APITokenPrefix) && !c.GetBool(DatabaseUnavailableKey) {
+			authenticateSession(c, db, keys, tokenString)
 		}
+		c.Next()
+	}
+}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == authHeader {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token format"})
-			return
-		}
+type authError struct {
+	status  int
+	message string
+}
+
+func unauthorized(message string) *authError {
+	return &authError{status: http.StatusUnauthorized, message: message}
+}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+func authMiddleware(db *sql.DB, keys *auth.KeySet, allowAPITokens bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, fromCookie, err := requestToken(c)
+		if err == nil && fromCookie && !safeMethod(c.Request.Method) {
+			err = checkCSRF(c)
+		}
+		if err == nil && fromCookie {
+			c.Set("cookieSession", true)
+		}
+		if err == nil {
+			if strings.HasPrefix(tokenString, auth.APITokenPrefix) {
+				if allowAPITokens {
+					err = authenticateAPIToken(c, db, tokenString)
+				} else {
+					err = &authError{status: http.StatusForbidden, message: "API tokens cannot access this endpoint"}
+				}
+			} else {
+				err = authenticateSession(c, db, keys, tokenString)
 			}
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
+		}
 
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		if err != nil {
+			c.AbortWithStatusJSON(err.status, gin.H{"error": err.message})
 			return
 		}
+		c.Next()
+	}
+}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			userID, ok := claims["sub"].(float64)
-			if !ok {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID in token"})
-				return
-			}
-			c.Set("userID", int(userID)) 
-		} else {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid claims"})
-			return
+func requestToken(c *gin.Context) (string, bool, *authError) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		if cookie, err := c.Cookie(auth.AccessCookieName); err == nil && cookie != "" && !strings.HasPrefix(cookie, auth.APITokenPrefix) {
+			return cookie, true, nil
 		}
+		return "", false, unauthorized("Authorization header required")
+	}
 
-		c.Next()
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	if tokenString == authHeader {
+		return "", false, unauthorized("Invalid token format")
 	}
-}
\ No newline at end of file
+	return tokenString, false, nil
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func checkCSRF(c *gin.Context) *authError {
+	cookie, _ := c.Cookie(auth.CSRFCookieName)
+	if !auth.ValidCSRFToken(cookie, c.GetHeader(auth.CSRFHeaderName)) {
+		return &authError{status: http.StatusForbidden, message: "Invalid CSRF token"}
+	}
+	return nil
+}
+
+func authenticateSession(c *gin.Context, db *sql.DB, keys *auth.KeySet, tokenString string) *authError {
+	claims, err := keys.ParseAccessToken(tokenString)
+	if err != nil {
+		return unauthorized("Invalid token")
+	}
+
+	userID, ok := claims["sub"].(float64)
+	if !ok {
+		return unauthorized("Invalid user ID in token")
+	}
+
+	jti, ok := claims["jti"].(string)
+	if !ok || jti == "" {
+		return unauthorized("Invalid claims")
+	}
+
+	sessionID, ok := claims["sid"].(string)
+	if !ok || sessionID == "" {
+		return unauthorized("Invalid claims")
+	}
+
+	tokenVersion, ok := claims["ver"].(float64)
+	if !ok {
+		return unauthorized("Invalid claims")
+	}
+
+	var currentVersion int
+	var role string
+	var revoked, sessionActive bool
+	query := `
+		SELECT u.token_version, u.role,
+			EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $2),
+			EXISTS(SELECT 1 FROM sessions WHERE id = $3 AND user_id = u.id AND revoked_at IS NULL)
+		FROM users u
+		WHERE u.id = $1
+	`
+	err = db.QueryRowContext(c.Request.Context(), query, int(userID), jti, sessionID).Scan(&currentVersion, &role, &revoked, &sessionActive)
+	if err == sql.ErrNoRows {
+		return unauthorized("Invalid token")
+	}
+	if err != nil {
+		return &authError{status: http.StatusInternalServerError, message: "Failed to verify token"}
+	}
+	if revoked || !sessionActive || int(tokenVersion) != currentVersion {
+		return unauthorized("Token has been revoked")
+	}
+
+	db.ExecContext(c.Request.Context(), `UPDATE sessions SET last_seen_at = CURRENT_TIMESTAMP WHERE id = $1 AND last_seen_at < CURRENT_TIMESTAMP - INTERVAL '1 minute'`, sessionID)
+
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		return unauthorized("Invalid claims")
+	}
+
+	c.Set("userID", int(userID))
+	c.Set("tokenID", jti)
+	c.Set("sessionID", sessionID)
+	c.Set("tokenVersion", currentVersion)
+	c.Set("role", role)
+	c.Set("tokenExpiresAt", expiresAt.Time)
+	return nil
+}
+
+func authenticateAPIToken(c *gin.Context, db *sql.DB, tokenString string) *authError {
+	var tokenID, userID int
+	var role, scopes string
+	query := `
+		SELECT t.id, t.user_id, u.role, t.scopes
+		FROM api_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1 AND t.revoked_at IS NULL AND (t.expires_at IS NULL OR t.expires_at > CURRENT_TIMESTAMP) AND u.disabled_at IS NULL
+	`
+	err := db.QueryRowContext(c.Request.Context(), query, auth.HashToken(tokenString)).Scan(&tokenID, &userID, &role, &scopes)
+	if err == sql.ErrNoRows {
+		return unauthorized("Invalid token")
+	}
+	if err != nil {
+		return &authError{status: http.StatusInternalServerError, message: "Failed to verify token"}
+	}
+
+	db.ExecContext(c.Request.Context(), `UPDATE api_tokens SET last_used_at = CURRENT_TIMESTAMP WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')`, tokenID)
+
+	c.Set("userID", userID)
+	c.Set("role", role)
+	c.Set("apiTokenID", tokenID)
+	c.Set("tokenScopes", strings.Fields(scopes))
+	return nil
+}
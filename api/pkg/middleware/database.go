@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/database"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DatabaseUnavailableKey = "databaseUnavailable"
+	databaseRetryAfter     = 30
+)
+
+func RequireDatabase(conn *database.Conn) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !conn.Ready(c.Request.Context()) {
+			c.Header("Retry-After", strconv.Itoa(databaseRetryAfter))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "This feature is temporarily unavailable. Please try again later."})
+			return
+		}
+		c.Next()
+	}
+}
+
+func ProbeDatabase(conn *database.Conn) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !conn.Ready(c.Request.Context()) {
+			c.Set(DatabaseUnavailableKey, true)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
+	"github.com/gin-gonic/gin"
+)
+
+func RequireRole(min auth.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := auth.Role(c.GetString("role"))
+		if !role.AtLeast(min) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func RequirePermission(permission auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := auth.Role(c.GetString("role"))
+		if !role.Can(permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			return
+		}
+		c.Next()
+	}
+}
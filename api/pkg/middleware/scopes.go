@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
+	"github.com/gin-gonic/gin"
+)
+
+func RequireScope(scope auth.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, isAPIToken := c.Get("tokenScopes")
+		if !isAPIToken {
+			c.Next()
+			return
+		}
+
+		for _, granted := range value.([]string) {
+			if granted == string(scope) {
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Token is missing the " + string(scope) + " scope"})
+	}
+}
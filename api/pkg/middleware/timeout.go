@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const baseContextKey = "baseRequestContext"
+
+func Timeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		base := c.Request.Context()
+		if parent, ok := c.Get(baseContextKey); ok {
+			base = parent.(context.Context)
+		} else {
+			c.Set(baseContextKey, base)
+		}
+
+		ctx, cancel := context.WithTimeout(base, d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
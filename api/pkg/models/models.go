@@ -3,13 +3,18 @@ package models
 import "time"
 
 type User struct {
-	ID                int       `json:"id"`
-	Username          string    `json:"username"`
-	Email             string    `json:"email"`
-	PasswordHash      string    `json:"-"`
-	ProfilePictureURL *string   `json:"profilePictureUrl"`
-	Description       *string   `json:"description"`
-	CreatedAt         time.Time `json:"createdAt"`
+	ID                  int        `json:"id"`
+	Username            string     `json:"username"`
+	Email               string     `json:"email"`
+	EmailVerified       bool       `json:"emailVerified"`
+	TwoFactorEnabled    bool       `json:"twoFactorEnabled"`
+	Role                string     `json:"role"`
+	PasswordHash        string     `json:"-"`
+	ProfilePictureURL   *string    `json:"profilePictureUrl"`
+	Description         *string    `json:"description"`
+	CreatedAt           time.Time  `json:"createdAt"`
+	DeletionScheduledAt *time.Time `json:"deletionScheduledAt,omitempty"`
+	DisabledAt          *time.Time `json:"disabledAt,omitempty"`
 }
 
 type RegisterPayload struct {
@@ -19,8 +24,9 @@ type RegisterPayload struct {
 }
 
 type LoginPayload struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Identifier string `json:"identifier" binding:"required_without=Email"`
+	Email      string `json:"email"`
+	Password   string `json:"password" binding:"required"`
 }
 
 type UpdateProfilePayload struct {
@@ -33,4 +39,54 @@ type UpdateProfilePayload struct {
 type UpdatePasswordPayload struct {
 	CurrentPassword string `json:"currentPassword" binding:"required"`
 	NewPassword     string `json:"newPassword" binding:"required,min=8"`
-}
\ No newline at end of file
+}
+
+type RefreshPayload struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type VerifyEmailPayload struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ForgotPasswordPayload struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordPayload struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required,min=8"`
+}
+
+type TwoFactorLoginPayload struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode   string `json:"recoveryCode"`
+}
+
+type TwoFactorCodePayload struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorReauthPayload struct {
+	Password     string `json:"password"`
+	Code         string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+type UpdateRolePayload struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type CreateAPITokenPayload struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expiresInDays" binding:"min=0,max=365"`
+}
+
+type DeleteAccountPayload struct {
+	Password      string `json:"password"`
+	Code          string `json:"code"`
+	RecoveryCode  string `json:"recoveryCode"`
+	DeleteReviews bool   `json:"deleteReviews"`
+}
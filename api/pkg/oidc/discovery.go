@@ -0,0 +1,244 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type OIDCProvider struct {
+	config Config
+
+	mu        sync.Mutex
+	discovery *discoveryDocument
+	keys      map[string]interface{}
+	keysAt    time.Time
+}
+
+func NewOIDCProvider(config Config) *OIDCProvider {
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &OIDCProvider{config: config}
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.config.Name
+}
+
+func (p *OIDCProvider) document(ctx context.Context) (*discoveryDocument, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var doc discoveryDocument
+	if err := getJSON(ctx, p.config.DiscoveryURL, "", &doc); err != nil {
+		return nil, fmt.Errorf("oidc %s: discovery failed: %w", p.config.Name, err)
+	}
+	if doc.Issuer == "" || doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc %s: incomplete discovery document", p.config.Name)
+	}
+
+	p.discovery = &doc
+	return p.discovery, nil
+}
+
+func (p *OIDCProvider) key(ctx context.Context, jwksURI, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysAt) < time.Minute && p.keys != nil {
+		return nil, fmt.Errorf("oidc %s: unknown signing key %q", p.config.Name, kid)
+	}
+
+	var set auth.JWKS
+	if err := getJSON(ctx, jwksURI, "", &set); err != nil {
+		return nil, fmt.Errorf("oidc %s: fetching keys failed: %w", p.config.Name, err)
+	}
+
+	keys := make(map[string]interface{})
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if publicKey, err := k.PublicKey(); err == nil {
+			keys[k.Kid] = publicKey
+		}
+	}
+	p.keys = keys
+	p.keysAt = time.Now()
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("oidc %s: unknown signing key %q", p.config.Name, kid)
+	}
+	return key, nil
+}
+
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, redirectURL, state, nonce, verifier string) (string, error) {
+	doc, err := p.document(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {redirectURL},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	return appendQuery(doc.AuthorizationEndpoint, params), nil
+}
+
+func (p *OIDCProvider) Exchange(ctx context.Context, redirectURL, code, nonce, verifier string) (*Identity, error) {
+	doc, err := p.document(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+		"client_id":     {p.config.ClientID},
+		"client_secret": {p.config.ClientSecret},
+		"code_verifier": {verifier},
+	}
+	var tokens tokenResponse
+	if err := postForm(ctx, doc.TokenEndpoint, form, &tokens); err != nil {
+		return nil, fmt.Errorf("oidc %s: token exchange failed: %w", p.config.Name, err)
+	}
+	if tokens.Error != "" {
+		return nil, fmt.Errorf("oidc %s: token exchange failed: %s %s", p.config.Name, tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("oidc %s: token response has no id_token", p.config.Name)
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(tokens.IDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, doc.JWKSURI, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "PS256", "EdDSA"}),
+		jwt.WithIssuer(doc.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("oidc %s: invalid id_token: %w", p.config.Name, err)
+	}
+
+	if claimNonce, _ := claims["nonce"].(string); claimNonce != nonce {
+		return nil, fmt.Errorf("oidc %s: id_token nonce mismatch", p.config.Name)
+	}
+
+	identity := identityFromClaims(claims)
+	if identity.Subject == "" {
+		return nil, fmt.Errorf("oidc %s: id_token has no subject", p.config.Name)
+	}
+
+	if identity.Email == "" && doc.UserinfoEndpoint != "" && tokens.AccessToken != "" {
+		userinfo := map[string]interface{}{}
+		if err := getJSON(ctx, doc.UserinfoEndpoint, tokens.AccessToken, &userinfo); err == nil {
+			if sub, _ := userinfo["sub"].(string); sub == identity.Subject {
+				extra := identityFromClaims(userinfo)
+				identity.Email, identity.EmailVerified = extra.Email, extra.EmailVerified
+			}
+		}
+	}
+
+	return identity, nil
+}
+
+func identityFromClaims(claims map[string]interface{}) *Identity {
+	identity := &Identity{}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	identity.PreferredUsername, _ = claims["preferred_username"].(string)
+	identity.Picture, _ = claims["picture"].(string)
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = v
+	case string:
+		identity.EmailVerified = v == "true"
+	}
+	return identity
+}
+
+func appendQuery(endpoint string, params url.Values) string {
+	if strings.Contains(endpoint, "?") {
+		return endpoint + "&" + params.Encode()
+	}
+	return endpoint + "?" + params.Encode()
+}
+
+func getJSON(ctx context.Context, endpoint, bearer string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	return doJSON(req, v)
+}
+
+func postForm(ctx context.Context, endpoint string, form url.Values, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	return doJSON(req, v)
+}
+
+func doJSON(req *http.Request, v interface{}) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusBadRequest && resp.StatusCode != http.StatusUnauthorized {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, req.URL.Host)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,98 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	githubAuthorizeURL = "https://github.com/login/oauth/authorize"
+	githubTokenURL     = "https://github.com/login/oauth/access_token"
+	githubUserURL      = "https://api.github.com/user"
+	githubEmailsURL    = "https://api.github.com/user/emails"
+)
+
+type GitHubProvider struct {
+	config Config
+}
+
+func NewGitHubProvider(config Config) *GitHubProvider {
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"read:user", "user:email"}
+	}
+	return &GitHubProvider{config: config}
+}
+
+func (p *GitHubProvider) Name() string {
+	return p.config.Name
+}
+
+func (p *GitHubProvider) AuthCodeURL(ctx context.Context, redirectURL, state, nonce, verifier string) (string, error) {
+	params := url.Values{
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {redirectURL},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {codeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+		"allow_signup":          {"true"},
+	}
+	return appendQuery(githubAuthorizeURL, params), nil
+}
+
+func (p *GitHubProvider) Exchange(ctx context.Context, redirectURL, code, nonce, verifier string) (*Identity, error) {
+	form := url.Values{
+		"client_id":     {p.config.ClientID},
+		"client_secret": {p.config.ClientSecret},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+		"code_verifier": {verifier},
+	}
+	var tokens tokenResponse
+	if err := postForm(ctx, githubTokenURL, form, &tokens); err != nil {
+		return nil, fmt.Errorf("github: token exchange failed: %w", err)
+	}
+	if tokens.Error != "" || tokens.AccessToken == "" {
+		return nil, fmt.Errorf("github: token exchange failed: %s %s", tokens.Error, tokens.ErrorDescription)
+	}
+
+	var user struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := getJSON(ctx, githubUserURL, tokens.AccessToken, &user); err != nil {
+		return nil, fmt.Errorf("github: fetching user failed: %w", err)
+	}
+	if user.ID == 0 {
+		return nil, fmt.Errorf("github: user response has no id")
+	}
+
+	identity := &Identity{
+		Subject:           strconv.FormatInt(user.ID, 10),
+		Name:              user.Name,
+		PreferredUsername: user.Login,
+		Picture:           user.AvatarURL,
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, githubEmailsURL, tokens.AccessToken, &emails); err == nil {
+		for _, e := range emails {
+			if e.Primary {
+				identity.Email = e.Email
+				identity.EmailVerified = e.Verified
+				break
+			}
+		}
+	}
+
+	return identity, nil
+}
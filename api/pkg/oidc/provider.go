@@ -0,0 +1,67 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/config"
+)
+
+type Identity struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+	Picture           string
+}
+
+type Provider interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, redirectURL, state, nonce, verifier string) (string, error)
+	Exchange(ctx context.Context, redirectURL, code, nonce, verifier string) (*Identity, error)
+}
+
+type Config struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	DiscoveryURL string
+	Scopes       []string
+}
+
+const googleDiscoveryURL = "https://accounts.google.com/.well-known/openid-configuration"
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+func Providers(configs []config.OAuthProvider) map[string]Provider {
+	providers := make(map[string]Provider)
+	for _, c := range configs {
+		cfg := Config{
+			Name:         c.Name,
+			ClientID:     c.ClientID,
+			ClientSecret: c.ClientSecret,
+			DiscoveryURL: c.DiscoveryURL,
+			Scopes:       c.Scopes,
+		}
+
+		switch {
+		case cfg.Name == "github" && cfg.DiscoveryURL == "":
+			providers[cfg.Name] = NewGitHubProvider(cfg)
+		case cfg.Name == "google" && cfg.DiscoveryURL == "":
+			cfg.DiscoveryURL = googleDiscoveryURL
+			providers[cfg.Name] = NewOIDCProvider(cfg)
+		case cfg.DiscoveryURL != "":
+			providers[cfg.Name] = NewOIDCProvider(cfg)
+		}
+	}
+	return providers
+}
+
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
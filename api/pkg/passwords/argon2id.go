@@ -0,0 +1,112 @@
+package passwords
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/config"
+	"golang.org/x/crypto/argon2"
+)
+
+var errInvalidArgon2Hash = errors.New("passwords: invalid argon2id hash")
+
+type Argon2id struct {
+	Time       uint32
+	Memory     uint32
+	Threads    uint8
+	SaltLength int
+	KeyLength  uint32
+}
+
+func NewArgon2id() *Argon2id {
+	return &Argon2id{Time: 2, Memory: 19 * 1024, Threads: 1, SaltLength: 16, KeyLength: 32}
+}
+
+func NewArgon2idWith(cfg config.Argon2) *Argon2id {
+	a := NewArgon2id()
+	if cfg.Iterations > 0 {
+		a.Time = uint32(cfg.Iterations)
+	}
+	if cfg.MemoryKiB > 0 {
+		a.Memory = uint32(cfg.MemoryKiB)
+	}
+	if cfg.Parallelism > 0 {
+		a.Threads = uint8(cfg.Parallelism)
+	}
+	return a
+}
+
+func (a *Argon2id) Name() string {
+	return "argon2id"
+}
+
+func (a *Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, a.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Time, a.Memory, a.Threads, a.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, a.Memory, a.Time, a.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (a *Argon2id) Identifies(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func (a *Argon2id) Verify(encoded, password string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	candidate := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, candidate) == 1, nil
+}
+
+func (a *Argon2id) NeedsRehash(encoded string) bool {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params.Time != a.Time || params.Memory != a.Memory || params.Threads != a.Threads ||
+		len(salt) != a.SaltLength || uint32(len(key)) != a.KeyLength
+}
+
+func decodeArgon2id(encoded string) (*Argon2id, []byte, []byte, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, errInvalidArgon2Hash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, errInvalidArgon2Hash
+	}
+
+	params := &Argon2id{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return nil, nil, nil, errInvalidArgon2Hash
+	}
+	if params.Time == 0 || params.Memory == 0 || params.Threads == 0 {
+		return nil, nil, nil, errInvalidArgon2Hash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, errInvalidArgon2Hash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, errInvalidArgon2Hash
+	}
+	return params, salt, key, nil
+}
@@ -0,0 +1,84 @@
+package passwords
+
+import (
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/config"
+)
+
+var ErrUnknownFormat = errors.New("passwords: unrecognised hash format")
+
+type Hasher interface {
+	Name() string
+	Hash(password string) (string, error)
+	Verify(encoded, password string) (bool, error)
+	Identifies(encoded string) bool
+	NeedsRehash(encoded string) bool
+}
+
+const dummyPassword = "cinelume-dummy-password"
+
+type Manager struct {
+	current Hasher
+	hashers []Hasher
+
+	dummyOnce sync.Once
+	dummyHash string
+}
+
+func NewManager(current Hasher, legacy ...Hasher) *Manager {
+	return &Manager{current: current, hashers: append([]Hasher{current}, legacy...)}
+}
+
+func Default() *Manager {
+	return NewManager(NewArgon2id(), NewBcrypt())
+}
+
+func Named(name string, argon config.Argon2) *Manager {
+	switch name {
+	case "", "argon2id":
+		return NewManager(NewArgon2idWith(argon), NewBcrypt())
+	case "bcrypt":
+		return NewManager(NewBcrypt(), NewArgon2idWith(argon))
+	default:
+		log.Printf("Unknown password hasher %q, using argon2id", name)
+		return NewManager(NewArgon2idWith(argon), NewBcrypt())
+	}
+}
+
+func (m *Manager) Hash(password string) (string, error) {
+	return m.current.Hash(password)
+}
+
+func (m *Manager) Verify(encoded, password string) (ok bool, rehash bool, err error) {
+	if encoded == "" {
+		m.SimulateVerify(password)
+		return false, false, nil
+	}
+	for _, h := range m.hashers {
+		if !h.Identifies(encoded) {
+			continue
+		}
+		ok, err := h.Verify(encoded, password)
+		if err != nil || !ok {
+			return false, false, err
+		}
+		return true, h != m.current || h.NeedsRehash(encoded), nil
+	}
+	return false, false, ErrUnknownFormat
+}
+
+func (m *Manager) SimulateVerify(password string) {
+	m.dummyOnce.Do(func() {
+		hash, err := m.current.Hash(dummyPassword)
+		if err != nil {
+			log.Printf("Failed to create dummy password hash: %v", err)
+		}
+		m.dummyHash = hash
+	})
+	if m.dummyHash != "" {
+		m.current.Verify(m.dummyHash, password)
+	}
+}
@@ -1,48 +1,93 @@
 package routes
 
 import (
-	"database/sql"
 	"net/http"
-	"os"
 	"strings"
+	"time"
 
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/config"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/database"
 	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/handlers"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/mailer"
 	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/middleware"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/oidc"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/store"
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(db *sql.DB) *gin.Engine {
+const (
+	requestTimeout = 10 * time.Second
+	exportTimeout  = 30 * time.Second
+	purgeTimeout   = 2 * time.Minute
+)
+
+func SetupRoutes(cfg *config.Config, conn *database.Conn, stores *store.Stores, keys *auth.KeySet) *gin.Engine {
+	db := conn.DB
 	router := gin.Default()
-	router.Use(corsMiddleware())
+	router.Use(corsMiddleware(cfg.AllowOrigins))
 
 	api := router.Group("/api")
-
-	tmdbHandler := handlers.NewTMDBHandler()
-	userHandler := handlers.NewUserHandler(db)
-	statsHandler := handlers.NewStatsHandler(db)
-	watchlistHandler := handlers.NewWatchlistHandler(db)
-	reviewHandler := handlers.NewReviewHandler(db)
-	movieHandler := handlers.NewMovieHandler(db)
-	tvHandler := handlers.NewTvHandler(db)
-	searchHandler := handlers.NewSearchHandler()
+	api.Use(middleware.Timeout(requestTimeout))
+
+	tmdbHandler := handlers.NewTMDBHandler(cfg.TMDB.APIKey)
+	userHandler := handlers.NewUserHandler(cfg, db, stores.Users, keys, mailer.New(cfg.Mail))
+	oauthHandler := handlers.NewOAuthHandler(userHandler, oidc.Providers(cfg.OAuthProviders))
+	statsHandler := handlers.NewStatsHandler(stores.Users, stores.Watchlist, stores.Reviews)
+	watchlistHandler := handlers.NewWatchlistHandler(stores.Watchlist)
+	reviewHandler := handlers.NewReviewHandler(stores.Reviews)
+	movieHandler := handlers.NewMovieHandler(cfg.TMDB.APIKey, stores.Reviews, stores.Watchlist)
+	tvHandler := handlers.NewTvHandler(cfg.TMDB.APIKey, stores.Reviews, stores.Watchlist)
+	searchHandler := handlers.NewSearchHandler(cfg.TMDB.APIKey)
+	adminHandler := handlers.NewAdminHandler(db, stores.Users)
+	keysHandler := handlers.NewKeysHandler(keys)
+
+	router.GET("/.well-known/jwks.json", keysHandler.JWKS)
 
 	api.GET("/search", searchHandler.Search)
 
 	api.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"ok":   true,
-			"tmdb": os.Getenv("TMDB_API_KEY") != "",
-			"db":   os.Getenv("DATABASE_URL") != "",
+		dbStatus := conn.Status(c.Request.Context())
+		tmdb := cfg.TMDB.APIKey != ""
+		c.JSON(http.StatusOK, gin.H{
+			"ok":       tmdb && dbStatus.State == database.StateUp,
+			"tmdb":     tmdb,
+			"db":       dbStatus.State == database.StateUp,
+			"database": dbStatus,
 		})
 	})
+	api.GET("/health/ready", func(c *gin.Context) {
+		dbStatus := conn.Status(c.Request.Context())
+		if dbStatus.State == database.StateUnavailable {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"ready": false, "database": dbStatus})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"ready": true, "database": dbStatus})
+	})
+
+	optionalAuth := middleware.OptionalAuth(db, keys)
+	probeDatabase := middleware.ProbeDatabase(conn)
 
-	api.GET("/movie/:id", movieHandler.GetMovieDetails)
-	api.GET("/tv/:id", tvHandler.GetTvDetails)
+	api.GET("/movie/:id", probeDatabase, optionalAuth, movieHandler.GetMovieDetails)
+	api.GET("/tv/:id", probeDatabase, optionalAuth, tvHandler.GetTvDetails)
+	api.GET("/auth/providers", oauthHandler.ListProviders)
 
-	api.POST("/users/register", userHandler.Register)
-	api.POST("/users/login", userHandler.Login)
-	api.GET("/users/:username/stats", statsHandler.GetUserStats)
-	api.GET("/users/:username/reviews", statsHandler.GetUserReviews)
+	stored := api.Group("/")
+	stored.Use(middleware.RequireDatabase(conn))
+	{
+		stored.POST("/users/register", userHandler.Register)
+		stored.POST("/users/login", userHandler.Login)
+		stored.POST("/users/login/2fa", userHandler.LoginSecondFactor)
+		stored.POST("/users/refresh", userHandler.Refresh)
+		stored.POST("/users/verify-email", userHandler.VerifyEmail)
+		stored.POST("/users/password/forgot", userHandler.RequestPasswordReset)
+		stored.POST("/users/password/reset", userHandler.ResetPassword)
+		stored.GET("/auth/:provider/login", oauthHandler.Login)
+		stored.GET("/auth/:provider/callback", oauthHandler.Callback)
+
+		stored.GET("/users/:username/stats", optionalAuth, statsHandler.GetUserStats)
+		stored.GET("/users/:username/reviews", statsHandler.GetUserReviews)
+	}
 
 	api.GET("/movies/popular", tmdbHandler.Proxy("movie/popular"))
 	api.GET("/movies/top_rated", tmdbHandler.Proxy("movie/top_rated"))
@@ -55,37 +100,67 @@ func SetupRoutes(db *sql.DB) *gin.Engine {
 
 	api.GET("/trending/all/day", tmdbHandler.Proxy("trending/all/day"))
 
-	protected := api.Group("/")
-	protected.Use(middleware.AuthMiddleware())
+	protected := stored.Group("/")
+	protected.Use(middleware.AuthMiddleware(db, keys))
 	{
-		protected.GET("/users/profile", userHandler.GetProfile)
+		protected.POST("/users/logout", userHandler.Logout)
+		protected.GET("/users/sessions", userHandler.ListSessions)
+		protected.DELETE("/users/sessions", userHandler.RevokeOtherSessions)
+		protected.DELETE("/users/sessions/:id", userHandler.RevokeSession)
 		protected.PUT("/users/profile", userHandler.UpdateProfile)
 		protected.PUT("/users/password", userHandler.UpdatePassword)
 		protected.GET("/users/upload-signature", userHandler.GetUploadSignature)
+		protected.POST("/users/verify-email/resend", userHandler.ResendEmailVerification)
+		protected.POST("/users/2fa/setup", userHandler.SetupTwoFactor)
+		protected.POST("/users/2fa/enable", userHandler.EnableTwoFactor)
+		protected.POST("/users/2fa/disable", userHandler.DisableTwoFactor)
+		protected.POST("/users/2fa/recovery-codes", userHandler.RegenerateRecoveryCodes)
+		protected.GET("/users/identities", oauthHandler.ListIdentities)
+		protected.POST("/users/identities/:provider", oauthHandler.LinkIdentity)
+		protected.DELETE("/users/identities/:provider", oauthHandler.UnlinkIdentity)
+		protected.GET("/users/tokens", userHandler.ListAPITokens)
+		protected.POST("/users/tokens", userHandler.CreateAPIToken)
+		protected.DELETE("/users/tokens/:id", userHandler.RevokeAPIToken)
+		protected.GET("/users/export", middleware.Timeout(exportTimeout), userHandler.ExportData)
+		protected.GET("/users/security-events", userHandler.ListSecurityEvents)
+		protected.DELETE("/users/account", userHandler.DeleteAccount)
+		protected.POST("/users/account/restore", userHandler.RestoreAccount)
+	}
+
+	scoped := stored.Group("/")
+	scoped.Use(middleware.APITokenMiddleware(db, keys))
+	{
+		scoped.GET("/users/profile", middleware.RequireScope(auth.ScopeProfileRead), userHandler.GetProfile)
 
-		protected.POST("/watchlist", watchlistHandler.AddItem)
-		protected.GET("/watchlist", watchlistHandler.GetWatchlist)
-		protected.DELETE("/watchlist/:id", watchlistHandler.RemoveItem)
+		scoped.POST("/watchlist", middleware.RequireScope(auth.ScopeWatchlistWrite), watchlistHandler.AddItem)
+		scoped.GET("/watchlist", middleware.RequireScope(auth.ScopeWatchlistRead), watchlistHandler.GetWatchlist)
+		scoped.DELETE("/watchlist/:id", middleware.RequireScope(auth.ScopeWatchlistWrite), watchlistHandler.RemoveItem)
 
-		protected.POST("/reviews", reviewHandler.AddReview)
-		protected.PUT("/reviews/:id", reviewHandler.UpdateReview)
+		scoped.GET("/reviews", middleware.RequireScope(auth.ScopeReviewsRead), reviewHandler.ListReviews)
+		scoped.POST("/reviews", middleware.RequireScope(auth.ScopeReviewsWrite), reviewHandler.AddReview)
+		scoped.PUT("/reviews/:id", middleware.RequireScope(auth.ScopeReviewsWrite), reviewHandler.UpdateReview)
+	}
+
+	stored.GET("/cron/purge", middleware.RequireCronSecret(cfg.CronSecret), middleware.Timeout(purgeTimeout), adminHandler.RunScheduledPurge)
+
+	admin := stored.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(db, keys), middleware.RequireRole(auth.RoleModerator))
+	{
+		admin.GET("/me", adminHandler.Me)
+		admin.GET("/users", middleware.RequirePermission(auth.PermissionViewUsers), adminHandler.ListUsers)
+		admin.PUT("/users/:id/role", middleware.RequirePermission(auth.PermissionManageRoles), adminHandler.UpdateUserRole)
+		admin.GET("/audit-events", middleware.RequirePermission(auth.PermissionViewAuditLog), adminHandler.ListAuditEvents)
+		admin.POST("/accounts/purge", middleware.RequirePermission(auth.PermissionManageUsers), middleware.Timeout(purgeTimeout), adminHandler.PurgeDeletedAccounts)
 	}
 
 	return router
 }
 
-func corsMiddleware() gin.HandlerFunc {
-	allowed := strings.Split(os.Getenv("ALLOW_ORIGINS"), ",")
-	for i := range allowed {
-		allowed[i] = strings.TrimSpace(allowed[i])
-	}
+func corsMiddleware(allowed []string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		origin := c.GetHeader("Origin")
 		allow := ""
 		for _, a := range allowed {
-			if a == "" {
-				continue
-			}
 			if a == origin {
 				allow = origin
 				break
@@ -102,7 +177,7 @@ func corsMiddleware() gin.HandlerFunc {
 			c.Header("Access-Control-Allow-Origin", allow)
 			c.Header("Vary", "Origin")
 			c.Header("Access-Control-Allow-Credentials", "true")
-			c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept, Authorization, X-Requested-With")
+			c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept, Authorization, X-Requested-With, X-CSRF-Token, X-Session-Mode")
 			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		}
 		if c.Request.Method == http.MethodOptions {
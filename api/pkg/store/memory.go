@@ -0,0 +1,726 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/models"
+)
+
+type memoryUser struct {
+	user          models.User
+	passwordHash  string
+	tokenVersion  int
+	keepReviews   bool
+	totpSecret    string
+	totpStep      int64
+	recoveryCodes []string
+	resets        []*memoryReset
+}
+
+type memoryReset struct {
+	tokenHash string
+	createdAt time.Time
+	expiresAt time.Time
+	used      bool
+}
+
+type usernameChange struct {
+	userID    int
+	username  string
+	changedAt time.Time
+}
+
+type memoryWatchlistItem struct {
+	userID int
+	item   WatchlistItem
+}
+
+type memoryReview struct {
+	userID    int
+	review    Review
+	updatedAt time.Time
+}
+
+type memoryDB struct {
+	mu        sync.Mutex
+	nextID    int
+	users     map[int]*memoryUser
+	history   []usernameChange
+	watchlist []*memoryWatchlistItem
+	reviews   []*memoryReview
+}
+
+// NewMemory keeps users, watchlists and reviews in process. It has no
+// sessions to revoke, so bumping the token version is how it signs users out.
+// UserHandler and OAuthHandler also keep session, token, MFA and throttle
+// state that still needs Postgres.
+func NewMemory() *Stores {
+	db := &memoryDB{users: map[int]*memoryUser{}}
+	return &Stores{
+		Users:     &memoryUsers{db},
+		Watchlist: &memoryWatchlist{db},
+		Reviews:   &memoryReviews{db},
+	}
+}
+
+func (db *memoryDB) id() int {
+	db.nextID++
+	return db.nextID
+}
+
+func (db *memoryDB) usernameTaken(username string, exceptUserID int) bool {
+	for id, u := range db.users {
+		if id != exceptUserID && strings.EqualFold(u.user.Username, username) {
+			return true
+		}
+	}
+	cutoff := time.Now().Add(-UsernameHoldPeriod)
+	for _, h := range db.history {
+		if h.userID != exceptUserID && h.changedAt.After(cutoff) && strings.EqualFold(h.username, username) {
+			return true
+		}
+	}
+	return false
+}
+
+type memoryUsers struct {
+	db *memoryDB
+}
+
+func (s *memoryUsers) Create(ctx context.Context, username, email, passwordHash string) (int, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	return s.create(username, email, passwordHash)
+}
+
+func (s *memoryUsers) CreateFromIdentity(ctx context.Context, account ExternalAccount) (int, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	id, err := s.create(account.Username, account.Email, "")
+	if err != nil {
+		return 0, err
+	}
+	u := s.db.users[id]
+	u.user.EmailVerified = account.EmailVerified
+	if account.Picture != "" {
+		picture := account.Picture
+		u.user.ProfilePictureURL = &picture
+	}
+	return id, nil
+}
+
+func (s *memoryUsers) create(username, email, passwordHash string) (int, error) {
+	for _, u := range s.db.users {
+		if strings.EqualFold(u.user.Username, username) {
+			return 0, ErrUsernameTaken
+		}
+		if strings.EqualFold(u.user.Email, email) {
+			return 0, ErrEmailTaken
+		}
+	}
+
+	id := s.db.id()
+	s.db.users[id] = &memoryUser{
+		user: models.User{
+			ID:        id,
+			Username:  username,
+			Email:     email,
+			Role:      "user",
+			CreatedAt: time.Now(),
+		},
+		passwordHash: passwordHash,
+		keepReviews:  true,
+	}
+	return id, nil
+}
+
+func (s *memoryUsers) Get(ctx context.Context, userID int) (*models.User, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	user := u.user
+	return &user, nil
+}
+
+func (s *memoryUsers) Identity(ctx context.Context, userID int) (*auth.Identity, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	identity := &auth.Identity{UserID: userID, Username: u.user.Username, Role: u.user.Role, TokenVersion: u.tokenVersion}
+	if u.user.ProfilePictureURL != nil {
+		identity.Picture = *u.user.ProfilePictureURL
+	}
+	return identity, nil
+}
+
+func (s *memoryUsers) FindCredentials(ctx context.Context, identifier string) (*Credentials, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	byEmail := strings.Contains(identifier, "@")
+	for id, u := range s.db.users {
+		field := u.user.Username
+		if byEmail {
+			field = u.user.Email
+		}
+		if strings.EqualFold(field, identifier) {
+			return &Credentials{UserID: id, PasswordHash: u.passwordHash}, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memoryUsers) PasswordHash(ctx context.Context, userID int) (string, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return u.passwordHash, nil
+}
+
+func (s *memoryUsers) ReplacePasswordHash(ctx context.Context, userID int, oldHash, newHash string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if u, ok := s.db.users[userID]; ok && u.passwordHash == oldHash {
+		u.passwordHash = newHash
+	}
+	return nil
+}
+
+func (s *memoryUsers) UsernameTaken(ctx context.Context, username string, exceptUserID int) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	return s.db.usernameTaken(username, exceptUserID), nil
+}
+
+func (s *memoryUsers) ResolveUsername(ctx context.Context, username string) (int, string, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for id, u := range s.db.users {
+		if strings.EqualFold(u.user.Username, username) {
+			return id, u.user.Username, nil
+		}
+	}
+	return 0, "", ErrNotFound
+}
+
+func (s *memoryUsers) UsernameRedirect(ctx context.Context, oldUsername string) (string, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var latest *usernameChange
+	for i, h := range s.db.history {
+		if strings.EqualFold(h.username, oldUsername) && (latest == nil || !h.changedAt.Before(latest.changedAt)) {
+			latest = &s.db.history[i]
+		}
+	}
+	if latest == nil {
+		return "", ErrNotFound
+	}
+	u, ok := s.db.users[latest.userID]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return u.user.Username, nil
+}
+
+func (s *memoryUsers) UpdateProfile(ctx context.Context, userID int, update ProfileUpdate) (*ProfileChange, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	change := &ProfileChange{
+		PreviousUsername: u.user.Username,
+		Email:            u.user.Email,
+		Renamed:          !strings.EqualFold(update.Username, u.user.Username),
+	}
+	if update.Username != u.user.Username && s.db.usernameTaken(update.Username, userID) {
+		return nil, ErrUsernameTaken
+	}
+
+	description, picture := update.Description, update.ProfilePictureURL
+	u.user.Username = update.Username
+	u.user.Description = &description
+	u.user.ProfilePictureURL = &picture
+	if change.Renamed {
+		s.db.history = append(s.db.history, usernameChange{userID: userID, username: change.PreviousUsername, changedAt: time.Now()})
+	}
+	return change, nil
+}
+
+func (s *memoryUsers) SetPasswordHash(ctx context.Context, userID int, passwordHash string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	u.passwordHash = passwordHash
+	u.tokenVersion++
+	return nil
+}
+
+func (s *memoryUsers) ChangePassword(ctx context.Context, userID int, passwordHash, keepSessionID string) (*auth.Identity, int, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok {
+		return nil, 0, ErrNotFound
+	}
+	u.passwordHash = passwordHash
+	u.tokenVersion++
+
+	identity := &auth.Identity{UserID: userID, Username: u.user.Username, Role: u.user.Role, SessionID: keepSessionID, TokenVersion: u.tokenVersion}
+	if u.user.ProfilePictureURL != nil {
+		identity.Picture = *u.user.ProfilePictureURL
+	}
+	return identity, 0, nil
+}
+
+func (s *memoryUsers) CreatePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	u.resets = append(u.resets, &memoryReset{tokenHash: tokenHash, createdAt: time.Now(), expiresAt: expiresAt})
+	return nil
+}
+
+func (s *memoryUsers) CountPasswordResets(ctx context.Context, userID int, since time.Time) (int, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	count := 0
+	if u, ok := s.db.users[userID]; ok {
+		for _, r := range u.resets {
+			if r.createdAt.After(since) {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+func (s *memoryUsers) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	now := time.Now()
+	for id, u := range s.db.users {
+		for _, r := range u.resets {
+			if r.tokenHash != tokenHash || r.used || !r.expiresAt.After(now) {
+				continue
+			}
+			for _, other := range u.resets {
+				other.used = true
+			}
+			u.passwordHash = passwordHash
+			u.tokenVersion++
+			u.user.EmailVerified = true
+			return id, nil
+		}
+	}
+	return 0, ErrNotFound
+}
+
+func (s *memoryUsers) SetTOTPSecret(ctx context.Context, userID int, secret string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok || u.user.TwoFactorEnabled {
+		return ErrNotFound
+	}
+	u.totpSecret = secret
+	u.totpStep = 0
+	return nil
+}
+
+func (s *memoryUsers) TOTPSecret(ctx context.Context, userID int) (string, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return u.totpSecret, nil
+}
+
+func (s *memoryUsers) EnableTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok || u.user.TwoFactorEnabled {
+		return false, nil
+	}
+	u.user.TwoFactorEnabled = true
+	u.totpStep = step
+	u.recoveryCodes = append([]string(nil), recoveryCodeHashes...)
+	return true, nil
+}
+
+func (s *memoryUsers) DisableTOTP(ctx context.Context, userID int) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if u, ok := s.db.users[userID]; ok {
+		u.user.TwoFactorEnabled = false
+		u.totpSecret = ""
+		u.totpStep = 0
+		u.recoveryCodes = nil
+	}
+	return nil
+}
+
+func (s *memoryUsers) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if u, ok := s.db.users[userID]; ok {
+		u.recoveryCodes = append([]string(nil), codeHashes...)
+	}
+	return nil
+}
+
+func (s *memoryUsers) SetRole(ctx context.Context, userID int, role string) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok {
+		return false, ErrNotFound
+	}
+	if u.user.Role == role {
+		return false, nil
+	}
+	u.user.Role = role
+	u.tokenVersion++
+	return true, nil
+}
+
+func (s *memoryUsers) SetDisabled(ctx context.Context, userID int, disabled bool) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok {
+		return false, ErrNotFound
+	}
+	if disabled == (u.user.DisabledAt != nil) {
+		return false, nil
+	}
+	u.user.DisabledAt = nil
+	if disabled {
+		now := time.Now()
+		u.user.DisabledAt = &now
+		u.tokenVersion++
+	}
+	return true, nil
+}
+
+func (s *memoryUsers) ScheduleDeletion(ctx context.Context, userID int, at time.Time, keepReviews bool) (*models.User, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	u.user.DeletionScheduledAt = &at
+	u.keepReviews = keepReviews
+	user := u.user
+	return &user, nil
+}
+
+func (s *memoryUsers) CancelDeletion(ctx context.Context, userID int) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok {
+		return false, ErrNotFound
+	}
+	if u.user.DeletionScheduledAt == nil {
+		return false, nil
+	}
+	u.user.DeletionScheduledAt = nil
+	u.keepReviews = true
+	return true, nil
+}
+
+func (s *memoryUsers) Delete(ctx context.Context, userID int) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(s.db.users, userID)
+
+	kept := s.db.watchlist[:0]
+	for _, w := range s.db.watchlist {
+		if w.userID != userID {
+			kept = append(kept, w)
+		}
+	}
+	s.db.watchlist = kept
+
+	history := s.db.history[:0]
+	for _, h := range s.db.history {
+		if h.userID != userID {
+			history = append(history, h)
+		}
+	}
+	s.db.history = history
+
+	reviews := s.db.reviews[:0]
+	for _, r := range s.db.reviews {
+		if r.userID == userID {
+			if !u.keepReviews {
+				continue
+			}
+			r.userID = 0
+		}
+		reviews = append(reviews, r)
+	}
+	s.db.reviews = reviews
+	return nil
+}
+
+type memoryWatchlist struct {
+	db *memoryDB
+}
+
+func (s *memoryWatchlist) Upsert(ctx context.Context, userID int, item WatchlistItem) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, w := range s.db.watchlist {
+		if w.userID == userID && w.item.MediaID == item.MediaID && w.item.MediaType == item.MediaType {
+			w.item.Status = item.Status
+			w.item.AddedAt = time.Now()
+			return nil
+		}
+	}
+
+	item.ID = s.db.id()
+	item.AddedAt = time.Now()
+	item.Rating = 0
+	s.db.watchlist = append(s.db.watchlist, &memoryWatchlistItem{userID: userID, item: item})
+	return nil
+}
+
+func (s *memoryWatchlist) List(ctx context.Context, userID int) ([]WatchlistItem, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var items []WatchlistItem
+	for _, w := range s.db.watchlist {
+		if w.userID != userID {
+			continue
+		}
+		item := w.item
+		for _, r := range s.db.reviews {
+			if r.userID == userID && r.review.MediaID == item.MediaID && r.review.MediaType == item.MediaType {
+				item.Rating = r.review.Rating
+			}
+		}
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].AddedAt.After(items[j].AddedAt) })
+	return items, nil
+}
+
+func (s *memoryWatchlist) Remove(ctx context.Context, userID, mediaID int) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	kept := s.db.watchlist[:0]
+	removed := false
+	for _, w := range s.db.watchlist {
+		if w.userID == userID && w.item.MediaID == mediaID {
+			removed = true
+			continue
+		}
+		kept = append(kept, w)
+	}
+	s.db.watchlist = kept
+	return removed, nil
+}
+
+func (s *memoryWatchlist) Status(ctx context.Context, userID, mediaID int, mediaType string) (string, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, w := range s.db.watchlist {
+		if w.userID == userID && w.item.MediaID == mediaID && w.item.MediaType == mediaType {
+			return w.item.Status, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+func (s *memoryWatchlist) StatusCounts(ctx context.Context, userID int) ([]StatusCount, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var counts []StatusCount
+	index := map[string]int{}
+	for _, w := range s.db.watchlist {
+		if w.userID != userID {
+			continue
+		}
+		i, ok := index[w.item.Status]
+		if !ok {
+			i = len(counts)
+			index[w.item.Status] = i
+			counts = append(counts, StatusCount{Status: w.item.Status})
+		}
+		counts[i].Count++
+	}
+	return counts, nil
+}
+
+type memoryReviews struct {
+	db *memoryDB
+}
+
+func (s *memoryReviews) Upsert(ctx context.Context, userID int, review Review) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	now := time.Now()
+	for _, r := range s.db.reviews {
+		if r.userID == userID && r.review.MediaID == review.MediaID && r.review.MediaType == review.MediaType {
+			r.review.Rating = review.Rating
+			r.review.Comment = review.Comment
+			r.updatedAt = now
+			return nil
+		}
+	}
+
+	review.ID = s.db.id()
+	review.CreatedAt = now
+	s.db.reviews = append(s.db.reviews, &memoryReview{userID: userID, review: review, updatedAt: now})
+	return nil
+}
+
+func (s *memoryReviews) Update(ctx context.Context, userID, reviewID, rating int, comment string) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, r := range s.db.reviews {
+		if r.review.ID == reviewID && r.userID == userID {
+			r.review.Rating = rating
+			r.review.Comment = comment
+			r.updatedAt = time.Now()
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *memoryReviews) newest(match func(*memoryReview) bool) []Review {
+	var reviews []Review
+	for _, r := range s.db.reviews {
+		if !match(r) {
+			continue
+		}
+		review := r.review
+		review.Username = "Deleted user"
+		review.ProfilePictureURL = ""
+		if u, ok := s.db.users[r.userID]; ok {
+			review.Username = u.user.Username
+			if u.user.ProfilePictureURL != nil {
+				review.ProfilePictureURL = *u.user.ProfilePictureURL
+			}
+		}
+		reviews = append(reviews, review)
+	}
+	sort.SliceStable(reviews, func(i, j int) bool { return reviews[i].CreatedAt.After(reviews[j].CreatedAt) })
+	return reviews
+}
+
+func (s *memoryReviews) ListForMedia(ctx context.Context, mediaID int, mediaType string) ([]Review, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	return s.newest(func(r *memoryReview) bool {
+		return r.review.MediaID == mediaID && r.review.MediaType == mediaType
+	}), nil
+}
+
+func (s *memoryReviews) ListByUser(ctx context.Context, userID, limit int) ([]Review, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	reviews := s.newest(func(r *memoryReview) bool { return r.userID == userID })
+	if len(reviews) > limit {
+		reviews = reviews[:limit]
+	}
+	return reviews, nil
+}
+
+func (s *memoryReviews) Summary(ctx context.Context, userID int) (ReviewSummary, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var summary ReviewSummary
+	total := 0
+	for _, r := range s.db.reviews {
+		if r.userID == userID {
+			total += r.review.Rating
+			summary.Count++
+		}
+	}
+	if summary.Count > 0 {
+		summary.MeanScore = float64(total) / float64(summary.Count)
+	}
+	return summary, nil
+}
+
+func (s *memoryReviews) FindByUserMedia(ctx context.Context, userID, mediaID int, mediaType string) (*Review, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, r := range s.db.reviews {
+		if r.userID == userID && r.review.MediaID == mediaID && r.review.MediaType == mediaType {
+			review := r.review
+			return &review, nil
+		}
+	}
+	return nil, ErrNotFound
+}
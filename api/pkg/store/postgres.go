@@ -0,0 +1,697 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/accounts"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/models"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func NewPostgres(db *sql.DB) *Stores {
+	return &Stores{
+		Users:     &postgresUsers{db: db},
+		Watchlist: &postgresWatchlist{db: db},
+		Reviews:   &postgresReviews{db: db},
+	}
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func uniqueViolation(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+		return err
+	}
+	if strings.Contains(pgErr.ConstraintName, "email") {
+		return ErrEmailTaken
+	}
+	return ErrUsernameTaken
+}
+
+type postgresUsers struct {
+	db *sql.DB
+}
+
+func (s *postgresUsers) Create(ctx context.Context, username, email, passwordHash string) (int, error) {
+	var userID int
+	query := `INSERT INTO users (username, email, password_hash) VALUES ($1, $2, $3) RETURNING id`
+	err := s.db.QueryRowContext(ctx, query, username, email, passwordHash).Scan(&userID)
+	if err != nil {
+		return 0, uniqueViolation(err)
+	}
+	return userID, nil
+}
+
+func (s *postgresUsers) CreateFromIdentity(ctx context.Context, account ExternalAccount) (int, error) {
+	var picture, verifiedAt interface{}
+	if account.Picture != "" {
+		picture = account.Picture
+	}
+	if account.EmailVerified {
+		verifiedAt = time.Now()
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	query := `
+		INSERT INTO users (username, email, password_hash, profile_picture_url, email_verified_at)
+		VALUES ($1, $2, '', $3, $4)
+		RETURNING id
+	`
+	if err := tx.QueryRowContext(ctx, query, account.Username, account.Email, picture, verifiedAt).Scan(&userID); err != nil {
+		return 0, uniqueViolation(err)
+	}
+
+	query = `INSERT INTO user_identities (user_id, provider, subject, email, last_login_at) VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)`
+	if _, err := tx.ExecContext(ctx, query, userID, account.Provider, account.Subject, account.ProviderEmail); err != nil {
+		return 0, err
+	}
+	return userID, tx.Commit()
+}
+
+func (s *postgresUsers) Get(ctx context.Context, userID int) (*models.User, error) {
+	var user models.User
+	query := `
+		SELECT id, username, email, email_verified_at IS NOT NULL, totp_enabled_at IS NOT NULL, role, profile_picture_url, description, created_at, deletion_scheduled_at, disabled_at
+		FROM users WHERE id = $1
+	`
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&user.ID, &user.Username, &user.Email, &user.EmailVerified, &user.TwoFactorEnabled, &user.Role, &user.ProfilePictureURL, &user.Description, &user.CreatedAt, &user.DeletionScheduledAt, &user.DisabledAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *postgresUsers) Identity(ctx context.Context, userID int) (*auth.Identity, error) {
+	var identity auth.Identity
+	query := `SELECT id, username, profile_picture_url, role, token_version FROM users WHERE id = $1`
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&identity.UserID, &identity.Username, &identity.Picture, &identity.Role, &identity.TokenVersion)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (s *postgresUsers) FindCredentials(ctx context.Context, identifier string) (*Credentials, error) {
+	var creds Credentials
+	query := `SELECT id, password_hash FROM users WHERE LOWER(username) = LOWER($1)`
+	if strings.Contains(identifier, "@") {
+		query = `SELECT id, password_hash FROM users WHERE LOWER(email) = LOWER($1)`
+	}
+	err := s.db.QueryRowContext(ctx, query, identifier).Scan(&creds.UserID, &creds.PasswordHash)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &creds, nil
+}
+
+func (s *postgresUsers) PasswordHash(ctx context.Context, userID int) (string, error) {
+	var hash string
+	err := s.db.QueryRowContext(ctx, `SELECT password_hash FROM users WHERE id = $1`, userID).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	return hash, err
+}
+
+func (s *postgresUsers) ReplacePasswordHash(ctx context.Context, userID int, oldHash, newHash string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE users SET password_hash = $2 WHERE id = $1 AND password_hash = $3`, userID, newHash, oldHash)
+	return err
+}
+
+func usernameTaken(ctx context.Context, db queryRower, username string, exceptUserID int) (bool, error) {
+	var taken bool
+	query := `
+		SELECT EXISTS(SELECT 1 FROM users WHERE LOWER(username) = LOWER($1) AND id <> $2)
+			OR EXISTS(SELECT 1 FROM username_history WHERE LOWER(username) = LOWER($1) AND user_id <> $2 AND changed_at > $3)
+	`
+	err := db.QueryRowContext(ctx, query, username, exceptUserID, time.Now().Add(-UsernameHoldPeriod)).Scan(&taken)
+	return taken, err
+}
+
+func (s *postgresUsers) UsernameTaken(ctx context.Context, username string, exceptUserID int) (bool, error) {
+	return usernameTaken(ctx, s.db, username, exceptUserID)
+}
+
+func (s *postgresUsers) ResolveUsername(ctx context.Context, username string) (int, string, error) {
+	var userID int
+	var current string
+	err := s.db.QueryRowContext(ctx, `SELECT id, username FROM users WHERE LOWER(username) = LOWER($1)`, username).Scan(&userID, &current)
+	if err == sql.ErrNoRows {
+		return 0, "", ErrNotFound
+	}
+	return userID, current, err
+}
+
+func (s *postgresUsers) UsernameRedirect(ctx context.Context, oldUsername string) (string, error) {
+	var current string
+	query := `
+		SELECT u.username
+		FROM username_history h
+		JOIN users u ON u.id = h.user_id
+		WHERE LOWER(h.username) = LOWER($1)
+		ORDER BY h.changed_at DESC
+		LIMIT 1
+	`
+	err := s.db.QueryRowContext(ctx, query, oldUsername).Scan(&current)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	return current, err
+}
+
+func (s *postgresUsers) UpdateProfile(ctx context.Context, userID int, update ProfileUpdate) (*ProfileChange, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var change ProfileChange
+	err = tx.QueryRowContext(ctx, `SELECT username, email FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&change.PreviousUsername, &change.Email)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	change.Renamed = !strings.EqualFold(update.Username, change.PreviousUsername)
+	if update.Username != change.PreviousUsername {
+		taken, err := usernameTaken(ctx, tx, update.Username, userID)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, ErrUsernameTaken
+		}
+	}
+
+	query := `
+		UPDATE users
+		SET username = $1, description = $2, profile_picture_url = $3
+		WHERE id = $4
+	`
+	_, err = tx.ExecContext(ctx, query, update.Username, update.Description, update.ProfilePictureURL, userID)
+	if err == nil && change.Renamed {
+		_, err = tx.ExecContext(ctx, `INSERT INTO username_history (user_id, username) VALUES ($1, $2)`, userID, change.PreviousUsername)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		return nil, uniqueViolation(err)
+	}
+	return &change, nil
+}
+
+var signOutEverywhere = []string{
+	`UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL`,
+	`UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL`,
+	`UPDATE password_reset_tokens SET used_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND used_at IS NULL`,
+	`DELETE FROM mfa_challenges WHERE user_id = $1`,
+}
+
+func (s *postgresUsers) updateAndSignOut(ctx context.Context, userID int, query string, args ...interface{}) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, userID).Scan(&exists); err != nil {
+			return false, err
+		}
+		if !exists {
+			return false, ErrNotFound
+		}
+		return false, nil
+	}
+
+	for _, revocation := range signOutEverywhere {
+		if _, err := tx.ExecContext(ctx, revocation, userID); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+func (s *postgresUsers) SetPasswordHash(ctx context.Context, userID int, passwordHash string) error {
+	query := `UPDATE users SET password_hash = $1, token_version = token_version + 1 WHERE id = $2`
+	_, err := s.updateAndSignOut(ctx, userID, query, passwordHash, userID)
+	return err
+}
+
+func (s *postgresUsers) ChangePassword(ctx context.Context, userID int, passwordHash, keepSessionID string) (*auth.Identity, int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	identity := auth.Identity{SessionID: keepSessionID}
+	query := `
+		UPDATE users SET password_hash = $1, token_version = token_version + 1
+		WHERE id = $2
+		RETURNING id, username, profile_picture_url, role, token_version
+	`
+	err = tx.QueryRowContext(ctx, query, passwordHash, userID).Scan(&identity.UserID, &identity.Username, &identity.Picture, &identity.Role, &identity.TokenVersion)
+	if err == sql.ErrNoRows {
+		return nil, 0, ErrNotFound
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	res, err := tx.ExecContext(ctx, `UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL`, userID, keepSessionID)
+	if err != nil {
+		return nil, 0, err
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND session_id <> $2 AND revoked_at IS NULL`, userID, keepSessionID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, 0, err
+	}
+
+	revoked, _ := res.RowsAffected()
+	return &identity, int(revoked), nil
+}
+
+func (s *postgresUsers) CreatePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	query := `INSERT INTO password_reset_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`
+	_, err := s.db.ExecContext(ctx, query, userID, tokenHash, expiresAt)
+	return err
+}
+
+func (s *postgresUsers) CountPasswordResets(ctx context.Context, userID int, since time.Time) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM password_reset_tokens WHERE user_id = $1 AND created_at > $2`
+	err := s.db.QueryRowContext(ctx, query, userID, since).Scan(&count)
+	return count, err
+}
+
+func (s *postgresUsers) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	query := `
+		UPDATE password_reset_tokens SET used_at = CURRENT_TIMESTAMP
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		RETURNING user_id
+	`
+	err = tx.QueryRowContext(ctx, query, tokenHash).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	query = `
+		UPDATE users
+		SET password_hash = $1, token_version = token_version + 1, email_verified_at = COALESCE(email_verified_at, CURRENT_TIMESTAMP)
+		WHERE id = $2
+	`
+	if _, err := tx.ExecContext(ctx, query, passwordHash, userID); err != nil {
+		return 0, err
+	}
+
+	for _, revocation := range signOutEverywhere {
+		if _, err := tx.ExecContext(ctx, revocation, userID); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE api_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL`, userID); err != nil {
+		return 0, err
+	}
+	return userID, tx.Commit()
+}
+
+func (s *postgresUsers) SetTOTPSecret(ctx context.Context, userID int, secret string) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE users SET totp_secret = $1, totp_last_step = 0 WHERE id = $2 AND totp_enabled_at IS NULL`, secret, userID)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *postgresUsers) TOTPSecret(ctx context.Context, userID int) (string, error) {
+	var secret sql.NullString
+	err := s.db.QueryRowContext(ctx, `SELECT totp_secret FROM users WHERE id = $1`, userID).Scan(&secret)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	return secret.String, err
+}
+
+func (s *postgresUsers) EnableTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `UPDATE users SET totp_enabled_at = CURRENT_TIMESTAMP, totp_last_step = $2 WHERE id = $1 AND totp_enabled_at IS NULL`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return false, nil
+	}
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (s *postgresUsers) DisableTOTP(ctx context.Context, userID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0 WHERE id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *postgresUsers) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID int, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *postgresUsers) SetRole(ctx context.Context, userID int, role string) (bool, error) {
+	query := `UPDATE users SET role = $1, token_version = token_version + 1 WHERE id = $2 AND role <> $1`
+	return s.updateAndSignOut(ctx, userID, query, role, userID)
+}
+
+func (s *postgresUsers) SetDisabled(ctx context.Context, userID int, disabled bool) (bool, error) {
+	if !disabled {
+		res, err := s.db.ExecContext(ctx, `UPDATE users SET disabled_at = NULL WHERE id = $1 AND disabled_at IS NOT NULL`, userID)
+		if err != nil {
+			return false, err
+		}
+		if rowsAffected, _ := res.RowsAffected(); rowsAffected > 0 {
+			return true, nil
+		}
+		if _, err := s.Get(ctx, userID); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+
+	query := `UPDATE users SET disabled_at = CURRENT_TIMESTAMP, token_version = token_version + 1 WHERE id = $1 AND disabled_at IS NULL`
+	return s.updateAndSignOut(ctx, userID, query, userID)
+}
+
+func (s *postgresUsers) ScheduleDeletion(ctx context.Context, userID int, at time.Time, keepReviews bool) (*models.User, error) {
+	query := `
+		UPDATE users
+		SET deletion_requested_at = CURRENT_TIMESTAMP, deletion_scheduled_at = $2, deletion_keep_reviews = $3
+		WHERE id = $1
+	`
+	res, err := s.db.ExecContext(ctx, query, userID, at, keepReviews)
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+	return s.Get(ctx, userID)
+}
+
+func (s *postgresUsers) CancelDeletion(ctx context.Context, userID int) (bool, error) {
+	query := `
+		UPDATE users
+		SET deletion_requested_at = NULL, deletion_scheduled_at = NULL, deletion_keep_reviews = TRUE
+		WHERE id = $1 AND deletion_scheduled_at IS NOT NULL
+	`
+	res, err := s.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected > 0 {
+		return true, nil
+	}
+	if _, err := s.Get(ctx, userID); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+func (s *postgresUsers) Delete(ctx context.Context, userID int) error {
+	err := accounts.Purge(ctx, s.db, userID)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
+
+type postgresWatchlist struct {
+	db *sql.DB
+}
+
+func (s *postgresWatchlist) Upsert(ctx context.Context, userID int, item WatchlistItem) error {
+	query := `
+		INSERT INTO watchlist_items (user_id, media_id, media_type, title, poster_path, status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, media_id, media_type)
+		DO UPDATE SET status = EXCLUDED.status, added_at = CURRENT_TIMESTAMP
+	`
+	_, err := s.db.ExecContext(ctx, query, userID, item.MediaID, item.MediaType, item.Title, item.PosterPath, item.Status)
+	return err
+}
+
+func (s *postgresWatchlist) List(ctx context.Context, userID int) ([]WatchlistItem, error) {
+	query := `
+		SELECT
+			wi.id, wi.media_id, wi.media_type, wi.title, wi.poster_path, wi.status, wi.added_at,
+			COALESCE(r.rating, 0) as user_rating
+		FROM watchlist_items wi
+		LEFT JOIN reviews r ON wi.user_id = r.user_id AND wi.media_id = r.media_id AND wi.media_type = r.media_type
+		WHERE wi.user_id = $1
+		ORDER BY wi.added_at DESC
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []WatchlistItem
+	for rows.Next() {
+		var item WatchlistItem
+		if err := rows.Scan(&item.ID, &item.MediaID, &item.MediaType, &item.Title, &item.PosterPath, &item.Status, &item.AddedAt, &item.Rating); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (s *postgresWatchlist) Remove(ctx context.Context, userID, mediaID int) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM watchlist_items WHERE user_id = $1 AND media_id = $2`, userID, mediaID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+func (s *postgresWatchlist) Status(ctx context.Context, userID, mediaID int, mediaType string) (string, error) {
+	var status string
+	query := `SELECT status FROM watchlist_items WHERE user_id = $1 AND media_id = $2 AND media_type = $3`
+	err := s.db.QueryRowContext(ctx, query, userID, mediaID, mediaType).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	return status, err
+}
+
+func (s *postgresWatchlist) StatusCounts(ctx context.Context, userID int) ([]StatusCount, error) {
+	query := `
+		SELECT status, COUNT(*)
+		FROM watchlist_items
+		WHERE user_id = $1
+		GROUP BY status
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []StatusCount
+	for rows.Next() {
+		var count StatusCount
+		if err := rows.Scan(&count.Status, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
+
+type postgresReviews struct {
+	db *sql.DB
+}
+
+func (s *postgresReviews) Upsert(ctx context.Context, userID int, review Review) error {
+	query := `
+		INSERT INTO reviews (user_id, media_id, media_type, rating, comment, media_title, media_poster_path)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, media_id, media_type)
+		DO UPDATE SET rating = EXCLUDED.rating, comment = EXCLUDED.comment, updated_at = CURRENT_TIMESTAMP
+	`
+	_, err := s.db.ExecContext(ctx, query, userID, review.MediaID, review.MediaType, review.Rating, review.Comment, review.MediaTitle, review.MediaPosterPath)
+	return err
+}
+
+func (s *postgresReviews) Update(ctx context.Context, userID, reviewID, rating int, comment string) (bool, error) {
+	query := `
+		UPDATE reviews SET rating = $1, comment = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND user_id = $4
+	`
+	res, err := s.db.ExecContext(ctx, query, rating, comment, reviewID, userID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := res.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+func (s *postgresReviews) ListForMedia(ctx context.Context, mediaID int, mediaType string) ([]Review, error) {
+	query := `
+		SELECT r.id, r.rating, r.comment, r.created_at, COALESCE(u.username, 'Deleted user'), u.profile_picture_url
+		FROM reviews r
+		LEFT JOIN users u ON r.user_id = u.id
+		WHERE r.media_id = $1 AND r.media_type = $2
+		ORDER BY r.created_at DESC
+	`
+	rows, err := s.db.QueryContext(ctx, query, mediaID, mediaType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []Review
+	for rows.Next() {
+		review := Review{MediaID: mediaID, MediaType: mediaType}
+		var picture sql.NullString
+		if err := rows.Scan(&review.ID, &review.Rating, &review.Comment, &review.CreatedAt, &review.Username, &picture); err != nil {
+			return nil, err
+		}
+		review.ProfilePictureURL = picture.String
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+func (s *postgresReviews) ListByUser(ctx context.Context, userID, limit int) ([]Review, error) {
+	query := `
+		SELECT r.id, r.media_id, r.media_type, r.media_title, r.media_poster_path, r.rating, r.comment, r.created_at
+		FROM reviews r
+		WHERE r.user_id = $1
+		ORDER BY r.created_at DESC
+		LIMIT $2
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []Review
+	for rows.Next() {
+		var review Review
+		var poster sql.NullString
+		if err := rows.Scan(&review.ID, &review.MediaID, &review.MediaType, &review.MediaTitle, &poster, &review.Rating, &review.Comment, &review.CreatedAt); err != nil {
+			return nil, err
+		}
+		review.MediaPosterPath = poster.String
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+func (s *postgresReviews) Summary(ctx context.Context, userID int) (ReviewSummary, error) {
+	var summary ReviewSummary
+	query := `SELECT COALESCE(AVG(rating), 0), COUNT(*) FROM reviews WHERE user_id = $1`
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&summary.MeanScore, &summary.Count)
+	return summary, err
+}
+
+func (s *postgresReviews) FindByUserMedia(ctx context.Context, userID, mediaID int, mediaType string) (*Review, error) {
+	review := Review{MediaID: mediaID, MediaType: mediaType}
+	query := `SELECT id, rating FROM reviews WHERE user_id = $1 AND media_id = $2 AND media_type = $3`
+	err := s.db.QueryRowContext(ctx, query, userID, mediaID, mediaType).Scan(&review.ID, &review.Rating)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
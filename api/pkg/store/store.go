@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/models"
+)
+
+var (
+	ErrNotFound      = errors.New("store: not found")
+	ErrUsernameTaken = errors.New("Username is already taken")
+	ErrEmailTaken    = errors.New("An account with this email already exists")
+)
+
+const UsernameHoldPeriod = 90 * 24 * time.Hour
+
+type Credentials struct {
+	UserID       int
+	PasswordHash string
+}
+
+type ProfileUpdate struct {
+	Username          string
+	Description       string
+	ProfilePictureURL string
+}
+
+type ProfileChange struct {
+	PreviousUsername string
+	Email            string
+	Renamed          bool
+}
+
+type ExternalAccount struct {
+	Username      string
+	Email         string
+	Picture       string
+	EmailVerified bool
+	Provider      string
+	Subject       string
+	ProviderEmail string
+}
+
+type UserStore interface {
+	Create(ctx context.Context, username, email, passwordHash string) (int, error)
+	CreateFromIdentity(ctx context.Context, account ExternalAccount) (int, error)
+	Get(ctx context.Context, userID int) (*models.User, error)
+	Identity(ctx context.Context, userID int) (*auth.Identity, error)
+	FindCredentials(ctx context.Context, identifier string) (*Credentials, error)
+	PasswordHash(ctx context.Context, userID int) (string, error)
+	ReplacePasswordHash(ctx context.Context, userID int, oldHash, newHash string) error
+	UsernameTaken(ctx context.Context, username string, exceptUserID int) (bool, error)
+	ResolveUsername(ctx context.Context, username string) (int, string, error)
+	UsernameRedirect(ctx context.Context, oldUsername string) (string, error)
+	UpdateProfile(ctx context.Context, userID int, update ProfileUpdate) (*ProfileChange, error)
+	SetPasswordHash(ctx context.Context, userID int, passwordHash string) error
+	ChangePassword(ctx context.Context, userID int, passwordHash, keepSessionID string) (*auth.Identity, int, error)
+	CreatePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error
+	CountPasswordResets(ctx context.Context, userID int, since time.Time) (int, error)
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error)
+	SetTOTPSecret(ctx context.Context, userID int, secret string) error
+	TOTPSecret(ctx context.Context, userID int) (string, error)
+	EnableTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) (bool, error)
+	DisableTOTP(ctx context.Context, userID int) error
+	ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error
+	SetRole(ctx context.Context, userID int, role string) (bool, error)
+	SetDisabled(ctx context.Context, userID int, disabled bool) (bool, error)
+	ScheduleDeletion(ctx context.Context, userID int, at time.Time, keepReviews bool) (*models.User, error)
+	CancelDeletion(ctx context.Context, userID int) (bool, error)
+	Delete(ctx context.Context, userID int) error
+}
+
+type WatchlistItem struct {
+	ID         int       `json:"id"`
+	MediaID    int       `json:"mediaId"`
+	MediaType  string    `json:"mediaType"`
+	Title      string    `json:"title"`
+	PosterPath string    `json:"posterPath"`
+	Status     string    `json:"status"`
+	AddedAt    time.Time `json:"addedAt"`
+	Rating     int       `json:"rating"`
+}
+
+type StatusCount struct {
+	Status string `json:"status"`
+	Count  int    `json:"count"`
+}
+
+type WatchlistStore interface {
+	Upsert(ctx context.Context, userID int, item WatchlistItem) error
+	List(ctx context.Context, userID int) ([]WatchlistItem, error)
+	Remove(ctx context.Context, userID, mediaID int) (bool, error)
+	Status(ctx context.Context, userID, mediaID int, mediaType string) (string, error)
+	StatusCounts(ctx context.Context, userID int) ([]StatusCount, error)
+}
+
+type Review struct {
+	ID                int
+	MediaID           int
+	MediaType         string
+	MediaTitle        string
+	MediaPosterPath   string
+	Rating            int
+	Comment           string
+	CreatedAt         time.Time
+	Username          string
+	ProfilePictureURL string
+}
+
+type ReviewSummary struct {
+	MeanScore float64
+	Count     int
+}
+
+type ReviewStore interface {
+	Upsert(ctx context.Context, userID int, review Review) error
+	Update(ctx context.Context, userID, reviewID, rating int, comment string) (bool, error)
+	ListForMedia(ctx context.Context, mediaID int, mediaType string) ([]Review, error)
+	ListByUser(ctx context.Context, userID, limit int) ([]Review, error)
+	Summary(ctx context.Context, userID int) (ReviewSummary, error)
+	FindByUserMedia(ctx context.Context, userID, mediaID int, mediaType string) (*Review, error)
+}
+
+type Stores struct {
+	Users     UserStore
+	Watchlist WatchlistStore
+	Reviews   ReviewStore
+}
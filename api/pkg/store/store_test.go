@@ -0,0 +1,436 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/config"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/database"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/database/migrations"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/store"
+)
+
+// The Postgres implementation runs only when TEST_DATABASE_URL points at a
+// database the tests may wipe.
+func implementations(t *testing.T) map[string]func(t *testing.T) *store.Stores {
+	impls := map[string]func(t *testing.T) *store.Stores{
+		"memory": func(t *testing.T) *store.Stores { return store.NewMemory() },
+	}
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		return impls
+	}
+	ctx := context.Background()
+	db, err := database.Connect(ctx, config.Database{URL: url, MaxOpenConns: 4, MaxIdleConns: 2})
+	if err != nil {
+		t.Fatalf("connecting to TEST_DATABASE_URL: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := migrations.Up(ctx, db); err != nil {
+		t.Fatalf("migrating test database: %v", err)
+	}
+
+	impls["postgres"] = func(t *testing.T) *store.Stores {
+		if _, err := db.ExecContext(ctx, `TRUNCATE users, username_history, watchlist_items, reviews, password_reset_tokens RESTART IDENTITY CASCADE`); err != nil {
+			t.Fatalf("resetting test database: %v", err)
+		}
+		return store.NewPostgres(db)
+	}
+	return impls
+}
+
+func forEachStore(t *testing.T, test func(t *testing.T, s *store.Stores)) {
+	for name, open := range implementations(t) {
+		t.Run(name, func(t *testing.T) { test(t, open(t)) })
+	}
+}
+
+func createUser(t *testing.T, s *store.Stores, username string) int {
+	t.Helper()
+	id, err := s.Users.Create(context.Background(), username, username+"@example.com", "hash-"+username)
+	if err != nil {
+		t.Fatalf("Create(%s): %v", username, err)
+	}
+	return id
+}
+
+func tokenVersion(t *testing.T, s *store.Stores, userID int) int {
+	t.Helper()
+	identity, err := s.Users.Identity(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("Identity(%d): %v", userID, err)
+	}
+	return identity.TokenVersion
+}
+
+func TestUsersCreateRejectsDuplicates(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *store.Stores) {
+		ctx := context.Background()
+		createUser(t, s, "alice")
+
+		if _, err := s.Users.Create(ctx, "ALICE", "other@example.com", "x"); !errors.Is(err, store.ErrUsernameTaken) {
+			t.Errorf("duplicate username: got %v, want ErrUsernameTaken", err)
+		}
+		if _, err := s.Users.Create(ctx, "bob", "alice@example.com", "x"); !errors.Is(err, store.ErrEmailTaken) {
+			t.Errorf("duplicate email: got %v, want ErrEmailTaken", err)
+		}
+	})
+}
+
+func TestUsersFindCredentials(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *store.Stores) {
+		ctx := context.Background()
+		id := createUser(t, s, "alice")
+
+		for _, identifier := range []string{"alice", "Alice", "alice@example.com"} {
+			creds, err := s.Users.FindCredentials(ctx, identifier)
+			if err != nil {
+				t.Fatalf("FindCredentials(%q): %v", identifier, err)
+			}
+			if creds.UserID != id || creds.PasswordHash != "hash-alice" {
+				t.Errorf("FindCredentials(%q) = %+v", identifier, creds)
+			}
+		}
+		if _, err := s.Users.FindCredentials(ctx, "nobody"); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("unknown user: got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestUsersSensitiveChangesBumpTokenVersion(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *store.Stores) {
+		ctx := context.Background()
+		id := createUser(t, s, "alice")
+		version := tokenVersion(t, s, id)
+
+		if err := s.Users.SetPasswordHash(ctx, id, "new-hash"); err != nil {
+			t.Fatal(err)
+		}
+		if got := tokenVersion(t, s, id); got != version+1 {
+			t.Fatalf("after SetPasswordHash: token version %d, want %d", got, version+1)
+		}
+		version++
+
+		if changed, err := s.Users.SetRole(ctx, id, "user"); err != nil || changed {
+			t.Fatalf("SetRole to the current role = %v, %v", changed, err)
+		}
+		if got := tokenVersion(t, s, id); got != version {
+			t.Fatalf("unchanged role bumped the token version to %d", got)
+		}
+		if changed, err := s.Users.SetRole(ctx, id, "admin"); err != nil || !changed {
+			t.Fatalf("SetRole = %v, %v", changed, err)
+		}
+		if got := tokenVersion(t, s, id); got != version+1 {
+			t.Fatalf("after SetRole: token version %d, want %d", got, version+1)
+		}
+		version++
+
+		if changed, err := s.Users.SetDisabled(ctx, id, true); err != nil || !changed {
+			t.Fatalf("SetDisabled(true) = %v, %v", changed, err)
+		}
+		if got := tokenVersion(t, s, id); got != version+1 {
+			t.Fatalf("after disabling: token version %d, want %d", got, version+1)
+		}
+		version++
+
+		if changed, err := s.Users.SetDisabled(ctx, id, false); err != nil || !changed {
+			t.Fatalf("SetDisabled(false) = %v, %v", changed, err)
+		}
+		if got := tokenVersion(t, s, id); got != version {
+			t.Fatalf("enabling bumped the token version to %d", got)
+		}
+
+		if err := s.Users.SetPasswordHash(ctx, id+100, "x"); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("SetPasswordHash on a missing user: got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestUsersRenameHoldsOldUsername(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *store.Stores) {
+		ctx := context.Background()
+		id := createUser(t, s, "alice")
+		other := createUser(t, s, "bob")
+
+		if _, err := s.Users.UpdateProfile(ctx, id, store.ProfileUpdate{Username: "alicia"}); err != nil {
+			t.Fatal(err)
+		}
+		if taken, err := s.Users.UsernameTaken(ctx, "alice", other); err != nil || !taken {
+			t.Errorf("old username taken = %v, %v; want held", taken, err)
+		}
+		if current, err := s.Users.UsernameRedirect(ctx, "alice"); err != nil || current != "alicia" {
+			t.Errorf("UsernameRedirect = %q, %v", current, err)
+		}
+		if _, err := s.Users.UpdateProfile(ctx, other, store.ProfileUpdate{Username: "alice"}); !errors.Is(err, store.ErrUsernameTaken) {
+			t.Errorf("taking a held username: got %v, want ErrUsernameTaken", err)
+		}
+	})
+}
+
+func TestUsersDeleteFollowsKeepReviews(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *store.Stores) {
+		ctx := context.Background()
+		keeper := createUser(t, s, "keeper")
+		leaver := createUser(t, s, "leaver")
+		for _, id := range []int{keeper, leaver} {
+			if err := s.Reviews.Upsert(ctx, id, store.Review{MediaID: 1, MediaType: "movie", MediaTitle: "Film", Rating: 7}); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Watchlist.Upsert(ctx, id, store.WatchlistItem{MediaID: 1, MediaType: "movie", Title: "Film", Status: "watching"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err := s.Users.ScheduleDeletion(ctx, leaver, time.Now(), false); err != nil {
+			t.Fatal(err)
+		}
+		for _, id := range []int{keeper, leaver} {
+			if err := s.Users.Delete(ctx, id); err != nil {
+				t.Fatalf("Delete(%d): %v", id, err)
+			}
+		}
+
+		reviews, err := s.Reviews.ListForMedia(ctx, 1, "movie")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reviews) != 1 || reviews[0].Username != "Deleted user" {
+			t.Fatalf("reviews after delete = %+v, want one anonymised review", reviews)
+		}
+		if items, _ := s.Watchlist.List(ctx, keeper); len(items) != 0 {
+			t.Errorf("watchlist survived delete: %+v", items)
+		}
+		if _, err := s.Users.Get(ctx, keeper); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("Get after delete: got %v, want ErrNotFound", err)
+		}
+		if err := s.Users.Delete(ctx, keeper); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("second Delete: got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestUsersCancelDeletion(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *store.Stores) {
+		ctx := context.Background()
+		id := createUser(t, s, "alice")
+
+		if restored, err := s.Users.CancelDeletion(ctx, id); err != nil || restored {
+			t.Fatalf("CancelDeletion without a schedule = %v, %v", restored, err)
+		}
+		user, err := s.Users.ScheduleDeletion(ctx, id, time.Now().Add(time.Hour), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.DeletionScheduledAt == nil {
+			t.Fatal("ScheduleDeletion did not return the scheduled time")
+		}
+		if restored, err := s.Users.CancelDeletion(ctx, id); err != nil || !restored {
+			t.Fatalf("CancelDeletion = %v, %v", restored, err)
+		}
+		if user, _ := s.Users.Get(ctx, id); user.DeletionScheduledAt != nil {
+			t.Errorf("deletion still scheduled at %v", user.DeletionScheduledAt)
+		}
+	})
+}
+
+func TestUsersChangePassword(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *store.Stores) {
+		ctx := context.Background()
+		id := createUser(t, s, "alice")
+		version := tokenVersion(t, s, id)
+
+		identity, _, err := s.Users.ChangePassword(ctx, id, "new-hash", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if identity.UserID != id || identity.TokenVersion != version+1 {
+			t.Errorf("ChangePassword identity = %+v, want token version %d", identity, version+1)
+		}
+		if hash, err := s.Users.PasswordHash(ctx, id); err != nil || hash != "new-hash" {
+			t.Errorf("PasswordHash = %q, %v", hash, err)
+		}
+		if _, _, err := s.Users.ChangePassword(ctx, id+100, "x", ""); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("unknown user: got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestUsersResetPassword(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *store.Stores) {
+		ctx := context.Background()
+		id := createUser(t, s, "alice")
+		version := tokenVersion(t, s, id)
+		expires := time.Now().Add(time.Hour)
+
+		for _, hash := range []string{"reset-1", "reset-2"} {
+			if err := s.Users.CreatePasswordReset(ctx, id, hash, expires); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if count, err := s.Users.CountPasswordResets(ctx, id, time.Now().Add(-time.Minute)); err != nil || count != 2 {
+			t.Errorf("CountPasswordResets = %d, %v, want 2", count, err)
+		}
+
+		userID, err := s.Users.ResetPassword(ctx, "reset-1", "new-hash")
+		if err != nil || userID != id {
+			t.Fatalf("ResetPassword = %d, %v", userID, err)
+		}
+		if hash, err := s.Users.PasswordHash(ctx, id); err != nil || hash != "new-hash" {
+			t.Errorf("PasswordHash = %q, %v", hash, err)
+		}
+		if got := tokenVersion(t, s, id); got != version+1 {
+			t.Errorf("token version %d, want %d", got, version+1)
+		}
+		if user, err := s.Users.Get(ctx, id); err != nil || !user.EmailVerified {
+			t.Errorf("Get after reset = %+v, %v, want verified email", user, err)
+		}
+		for _, hash := range []string{"reset-1", "reset-2", "unknown"} {
+			if _, err := s.Users.ResetPassword(ctx, hash, "x"); !errors.Is(err, store.ErrNotFound) {
+				t.Errorf("ResetPassword(%s) after reset: got %v, want ErrNotFound", hash, err)
+			}
+		}
+	})
+}
+
+func TestUsersTwoFactorLifecycle(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *store.Stores) {
+		ctx := context.Background()
+		id := createUser(t, s, "alice")
+
+		if err := s.Users.SetTOTPSecret(ctx, id, "SECRET"); err != nil {
+			t.Fatal(err)
+		}
+		if secret, err := s.Users.TOTPSecret(ctx, id); err != nil || secret != "SECRET" {
+			t.Fatalf("TOTPSecret = %q, %v", secret, err)
+		}
+		if enabled, err := s.Users.EnableTOTP(ctx, id, 10, []string{"a", "b"}); err != nil || !enabled {
+			t.Fatalf("EnableTOTP = %v, %v", enabled, err)
+		}
+		if enabled, err := s.Users.EnableTOTP(ctx, id, 11, []string{"c"}); err != nil || enabled {
+			t.Errorf("second EnableTOTP = %v, %v, want false", enabled, err)
+		}
+		if err := s.Users.SetTOTPSecret(ctx, id, "OTHER"); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("SetTOTPSecret while enabled: got %v, want ErrNotFound", err)
+		}
+		if user, err := s.Users.Get(ctx, id); err != nil || !user.TwoFactorEnabled {
+			t.Fatalf("Get after enabling = %+v, %v", user, err)
+		}
+
+		if err := s.Users.DisableTOTP(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+		if secret, err := s.Users.TOTPSecret(ctx, id); err != nil || secret != "" {
+			t.Errorf("TOTPSecret after disabling = %q, %v", secret, err)
+		}
+		if user, err := s.Users.Get(ctx, id); err != nil || user.TwoFactorEnabled {
+			t.Errorf("Get after disabling = %+v, %v", user, err)
+		}
+	})
+}
+
+func TestUsersCreateFromIdentity(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *store.Stores) {
+		ctx := context.Background()
+		createUser(t, s, "alice")
+
+		account := store.ExternalAccount{Username: "bob", Email: "bob@example.com", EmailVerified: true, Provider: "google", Subject: "sub-1", ProviderEmail: "Bob@example.com"}
+		id, err := s.Users.CreateFromIdentity(ctx, account)
+		if err != nil {
+			t.Fatal(err)
+		}
+		user, err := s.Users.Get(ctx, id)
+		if err != nil || user.Username != "bob" || !user.EmailVerified {
+			t.Errorf("Get = %+v, %v", user, err)
+		}
+		if hash, err := s.Users.PasswordHash(ctx, id); err != nil || hash != "" {
+			t.Errorf("PasswordHash = %q, %v, want empty", hash, err)
+		}
+
+		account.Username, account.Email, account.Subject = "alice", "carol@example.com", "sub-2"
+		if _, err := s.Users.CreateFromIdentity(ctx, account); !errors.Is(err, store.ErrUsernameTaken) {
+			t.Errorf("duplicate username: got %v, want ErrUsernameTaken", err)
+		}
+	})
+}
+
+func TestWatchlistCarriesReviewRating(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *store.Stores) {
+		ctx := context.Background()
+		id := createUser(t, s, "alice")
+
+		for _, item := range []store.WatchlistItem{
+			{MediaID: 1, MediaType: "movie", Title: "First", Status: "watching"},
+			{MediaID: 2, MediaType: "tv", Title: "Second", Status: "completed"},
+			{MediaID: 1, MediaType: "movie", Title: "First", Status: "completed"},
+		} {
+			if err := s.Watchlist.Upsert(ctx, id, item); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := s.Reviews.Upsert(ctx, id, store.Review{MediaID: 1, MediaType: "movie", MediaTitle: "First", Rating: 9}); err != nil {
+			t.Fatal(err)
+		}
+
+		items, err := s.Watchlist.List(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items) != 2 {
+			t.Fatalf("List returned %d items, want 2", len(items))
+		}
+		for _, item := range items {
+			if item.MediaID == 1 && (item.Rating != 9 || item.Status != "completed") {
+				t.Errorf("item 1 = %+v, want completed with rating 9", item)
+			}
+		}
+
+		counts, err := s.Watchlist.StatusCounts(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(counts) != 1 || counts[0].Status != "completed" || counts[0].Count != 2 {
+			t.Errorf("StatusCounts = %+v", counts)
+		}
+
+		if removed, err := s.Watchlist.Remove(ctx, id, 2); err != nil || !removed {
+			t.Fatalf("Remove = %v, %v", removed, err)
+		}
+		if _, err := s.Watchlist.Status(ctx, id, 2, "tv"); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("Status after Remove: got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestReviewsUpdateOnlyOwn(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *store.Stores) {
+		ctx := context.Background()
+		owner := createUser(t, s, "owner")
+		other := createUser(t, s, "other")
+		if err := s.Reviews.Upsert(ctx, owner, store.Review{MediaID: 5, MediaType: "movie", MediaTitle: "Film", Rating: 4}); err != nil {
+			t.Fatal(err)
+		}
+		review, err := s.Reviews.FindByUserMedia(ctx, owner, 5, "movie")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if updated, err := s.Reviews.Update(ctx, other, review.ID, 10, "mine now"); err != nil || updated {
+			t.Fatalf("Update by another user = %v, %v", updated, err)
+		}
+		if updated, err := s.Reviews.Update(ctx, owner, review.ID, 6, "better"); err != nil || !updated {
+			t.Fatalf("Update by owner = %v, %v", updated, err)
+		}
+
+		summary, err := s.Reviews.Summary(ctx, owner)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if summary.Count != 1 || summary.MeanScore != 6 {
+			t.Errorf("Summary = %+v", summary)
+		}
+	})
+}
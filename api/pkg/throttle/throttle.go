@@ -0,0 +1,137 @@
+package throttle
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type Policy struct {
+	Scope     string
+	Threshold int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	Window    time.Duration
+}
+
+var (
+	LoginIP = Policy{
+		Scope:     "login_ip",
+		Threshold: 20,
+		BaseDelay: 30 * time.Second,
+		MaxDelay:  time.Hour,
+		Window:    24 * time.Hour,
+	}
+	LoginAccount = Policy{
+		Scope:     "login_account",
+		Threshold: 5,
+		BaseDelay: 30 * time.Second,
+		MaxDelay:  time.Hour,
+		Window:    24 * time.Hour,
+	}
+	SecondFactor = Policy{
+		Scope:     "mfa_account",
+		Threshold: 5,
+		BaseDelay: time.Minute,
+		MaxDelay:  time.Hour,
+		Window:    24 * time.Hour,
+	}
+	PasswordResetIP = Policy{
+		Scope:     "password_reset_ip",
+		Threshold: 10,
+		BaseDelay: 15 * time.Minute,
+		MaxDelay:  24 * time.Hour,
+		Window:    time.Hour,
+	}
+	RegisterIP = Policy{
+		Scope:     "register_ip",
+		Threshold: 10,
+		BaseDelay: time.Minute,
+		MaxDelay:  24 * time.Hour,
+		Window:    24 * time.Hour,
+	}
+)
+
+type Throttle struct {
+	DB *sql.DB
+}
+
+func New(db *sql.DB) *Throttle {
+	return &Throttle{DB: db}
+}
+
+func (t *Throttle) Check(ctx context.Context, p Policy, key string) (time.Duration, error) {
+	var lockedUntil sql.NullTime
+	query := `SELECT locked_until FROM auth_throttles WHERE scope = $1 AND key = $2`
+	err := t.DB.QueryRowContext(ctx, query, p.Scope, key).Scan(&lockedUntil)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if !lockedUntil.Valid {
+		return 0, nil
+	}
+	if remaining := time.Until(lockedUntil.Time); remaining > 0 {
+		return remaining, nil
+	}
+	return 0, nil
+}
+
+func (t *Throttle) Record(ctx context.Context, p Policy, key string) (time.Duration, error) {
+	var failures int
+	query := `
+		INSERT INTO auth_throttles (scope, key, failures, last_failure_at)
+		VALUES ($1, $2, 1, CURRENT_TIMESTAMP)
+		ON CONFLICT (scope, key) DO UPDATE SET
+			failures = CASE
+				WHEN auth_throttles.last_failure_at < CURRENT_TIMESTAMP - make_interval(secs => $3) THEN 1
+				ELSE auth_throttles.failures + 1
+			END,
+			last_failure_at = CURRENT_TIMESTAMP
+		RETURNING failures
+	`
+	err := t.DB.QueryRowContext(ctx, query, p.Scope, key, p.Window.Seconds()).Scan(&failures)
+	if err != nil {
+		return 0, err
+	}
+
+	delay := p.delay(failures)
+	if delay == 0 {
+		return 0, nil
+	}
+
+	_, err = t.DB.ExecContext(ctx, `UPDATE auth_throttles SET locked_until = $3 WHERE scope = $1 AND key = $2`, p.Scope, key, time.Now().Add(delay))
+	return delay, err
+}
+
+func (t *Throttle) Reset(ctx context.Context, p Policy, key string) error {
+	_, err := t.DB.ExecContext(ctx, `DELETE FROM auth_throttles WHERE scope = $1 AND key = $2`, p.Scope, key)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.DB.ExecContext(ctx, `
+		DELETE FROM auth_throttles
+		WHERE last_failure_at < CURRENT_TIMESTAMP - INTERVAL '1 day'
+		AND (locked_until IS NULL OR locked_until < CURRENT_TIMESTAMP)
+	`)
+	return err
+}
+
+func (p Policy) delay(failures int) time.Duration {
+	if failures < p.Threshold {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.Threshold; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
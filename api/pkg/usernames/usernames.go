@@ -0,0 +1,75 @@
+package usernames
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+const (
+	MinLength = 3
+	MaxLength = 24
+)
+
+var (
+	ErrLength     = errors.New("Username must be between 3 and 24 characters")
+	ErrCharacters = errors.New("Username may only contain letters, numbers and underscores, and must start and end with a letter or number")
+	ErrReserved   = errors.New("This username is reserved")
+)
+
+var pattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9_]*[A-Za-z0-9])?$`)
+
+var reserved = map[string]bool{
+	"admin":           true,
+	"administrator":   true,
+	"api":             true,
+	"auth":            true,
+	"cinelume":        true,
+	"deleted_user":    true,
+	"forgot_password": true,
+	"help":            true,
+	"login":           true,
+	"logout":          true,
+	"me":              true,
+	"mod":             true,
+	"moderator":       true,
+	"movie":           true,
+	"movies":          true,
+	"null":            true,
+	"profile":         true,
+	"register":        true,
+	"reset_password":  true,
+	"root":            true,
+	"search":          true,
+	"settings":        true,
+	"staff":           true,
+	"support":         true,
+	"system":          true,
+	"tv":              true,
+	"undefined":       true,
+	"user":            true,
+	"users":           true,
+	"verify_email":    true,
+	"watchlist":       true,
+}
+
+func Normalize(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+func IsReserved(username string) bool {
+	return reserved[Normalize(username)]
+}
+
+func Validate(username string) error {
+	if len(username) < MinLength || len(username) > MaxLength {
+		return ErrLength
+	}
+	if !pattern.MatchString(username) {
+		return ErrCharacters
+	}
+	if IsReserved(username) {
+		return ErrReserved
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+"use client";
+
+import { useEffect } from 'react';
+import { useRouter } from 'next/navigation';
+import { useAuth } from '../../context/AuthContext';
+
+export default function AuthCallbackPage() {
+  const { loginWithTokens } = useAuth();
+  const router = useRouter();
+
+  useEffect(() => {
+    const params = new URLSearchParams(window.location.hash.slice(1));
+    const token = params.get('token');
+    const challengeToken = params.get('challengeToken');
+    window.history.replaceState(null, '', window.location.pathname);
+    if (challengeToken) {
+      router.replace('/login#challengeToken=' + encodeURIComponent(challengeToken));
+    } else if (token) {
+      loginWithTokens(token);
+    } else {
+      router.replace('/login?error=' + encodeURIComponent('Sign in failed'));
+    }
+  }, []);
+
+  return <div className="text-center p-10">Signing you in...</div>;
+}
@@ -14,7 +14,9 @@ interface User {
 interface AuthContextType {
   user: User | null;
   token: string | null;
-  login: (data: any) => Promise<void>;
+  login: (data: any) => Promise<{ challengeToken?: string }>;
+  completeTwoFactor: (challengeToken: string, code: string, recoveryCode?: string) => Promise<void>;
+  loginWithTokens: (token: string, refreshToken?: string) => void;
   register: (data: any) => Promise<void>;
   logout: () => void;
   updateUser: (newUserData: Partial<User>) => void;
@@ -40,18 +42,36 @@ export function AuthProvider({ children }: { children: ReactNode }) {
     }
   }, []);
 
+  const loginWithTokens = (token: string, refreshToken?: string) => {
+    localStorage.setItem('token', token);
+    if (refreshToken) {
+      localStorage.setItem('refreshToken', refreshToken);
+    } else {
+      localStorage.removeItem('refreshToken');
+    }
+    const decoded: { sub: number; username: string; pfp: string } = jwtDecode(token);
+    setUser({ sub: decoded.sub, username: decoded.username, pfp: decoded.pfp }); 
+    setToken(token);
+    router.push('/');
+  };
+
   const login = async (data: any) => {
     const response = await api.post('/users/login', data);
-    if (response.status === 200) {
-      const { token } = response.data;
-      localStorage.setItem('token', token);
-      const decoded: { sub: number; username: string; pfp: string } = jwtDecode(token);
-      setUser({ sub: decoded.sub, username: decoded.username, pfp: decoded.pfp }); 
-      setToken(token);
-      router.push('/');
-    } else {
+    if (response.status !== 200) {
       throw new Error('Login failed');
     }
+    if (response.data.mfaRequired) {
+      return { challengeToken: response.data.challengeToken };
+    }
+    const { token, refreshToken } = response.data;
+    loginWithTokens(token, refreshToken);
+    return {};
+  };
+
+  const completeTwoFactor = async (challengeToken: string, code: string, recoveryCode?: string) => {
+    const response = await api.post('/users/login/2fa', recoveryCode ? { challengeToken, recoveryCode } : { challengeToken, code });
+    const { token, refreshToken } = response.data;
+    loginWithTokens(token, refreshToken);
   };
   
   const updateUser = (newUserData: Partial<User>) => {
@@ -78,14 +98,20 @@ export function AuthProvider({ children }: { children: ReactNode }) {
   };
 
   const logout = () => {
+    const storedToken = localStorage.getItem('token');
+    const refreshToken = localStorage.getItem('refreshToken');
+    if (storedToken) {
+      api.post('/users/logout', { refreshToken }, { headers: { Authorization: `Bearer ${storedToken}` } }).catch(() => {});
+    }
     localStorage.removeItem('token');
+    localStorage.removeItem('refreshToken');
     setUser(null);
     setToken(null);
     router.push('/');
   };
 
   return (
-    <AuthContext.Provider value={{ user, token, login, register, logout, updateUser }}>
+    <AuthContext.Provider value={{ user, token, login, completeTwoFactor, loginWithTokens, register, logout, updateUser }}>
       {children}
     </AuthContext.Provider>
   );
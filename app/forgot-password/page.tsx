@@ -0,0 +1,41 @@
+"use client";
+
+import { useState } from 'react';
+import Link from 'next/link';
+import api from '@/lib/api';
+
+export default function ForgotPasswordPage() {
+  const [email, setEmail] = useState('');
+  const [message, setMessage] = useState('');
+  const [error, setError] = useState('');
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError('');
+    setMessage('');
+    try {
+      const response = await api.post('/users/password/forgot', { email });
+      setMessage(response.data.message);
+    } catch (err) {
+      setError('Something went wrong. Please try again.');
+    }
+  };
+
+  return (
+    <div className="container mx-auto max-w-sm mt-20">
+      <h1 className="text-3xl font-bold text-center mb-8 text-cyan-400">Forgot Password</h1>
+      <form onSubmit={handleSubmit} className="bg-gray-800 p-8 rounded-lg shadow-lg">
+        {error && <p className="bg-red-500/20 text-red-400 p-3 rounded mb-4">{error}</p>}
+        {message && <p className="bg-green-500/20 text-green-400 p-3 rounded mb-4">{message}</p>}
+        <div className="mb-6">
+          <label className="block text-gray-300 mb-2" htmlFor="email">Email</label>
+          <input type="email" id="email" value={email} onChange={(e) => setEmail(e.target.value)} className="w-full bg-gray-700 p-3 rounded-md focus:outline-none focus:ring-2 focus:ring-cyan-400" required />
+        </div>
+        <button type="submit" className="w-full bg-cyan-500 hover:bg-cyan-600 text-white font-bold py-3 rounded-md transition duration-300">Send Reset Link</button>
+      </form>
+      <p className="text-center text-gray-400 mt-4">
+        Remembered it? <Link href="/login" className="text-cyan-400 hover:underline">Back to login</Link>
+      </p>
+    </div>
+  );
+}
@@ -1,41 +1,103 @@
 "use client";
 
-import { useState } from 'react';
+import { useEffect, useState } from 'react';
 import { useAuth } from '../context/AuthContext';
 import Link from 'next/link';
+import api from '@/lib/api';
 
 export default function LoginPage() {
-  const [email, setEmail] = useState('');
+  const [identifier, setIdentifier] = useState('');
   const [password, setPassword] = useState('');
   const [error, setError] = useState('');
-  const { login } = useAuth();
+  const [providers, setProviders] = useState<string[]>([]);
+  const [challengeToken, setChallengeToken] = useState('');
+  const [code, setCode] = useState('');
+  const [useRecoveryCode, setUseRecoveryCode] = useState(false);
+  const { login, completeTwoFactor } = useAuth();
+
+  useEffect(() => {
+    const params = new URLSearchParams(window.location.search);
+    if (params.get('error')) setError(params.get('error') as string);
+    const hash = new URLSearchParams(window.location.hash.slice(1));
+    if (hash.get('challengeToken')) {
+      setChallengeToken(hash.get('challengeToken') as string);
+      window.history.replaceState(null, '', window.location.pathname);
+    }
+    api.get('/auth/providers').then((res) => setProviders(res.data.providers || [])).catch(() => {});
+  }, []);
 
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault();
     setError('');
     try {
-      await login({ email, password });
+      const result = await login({ identifier, password });
+      if (result.challengeToken) setChallengeToken(result.challengeToken);
     } catch (err) {
       setError('Failed to log in. Please check your credentials.');
     }
   };
 
+  const handleTwoFactorSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError('');
+    try {
+      await completeTwoFactor(challengeToken, code, useRecoveryCode ? code : undefined);
+    } catch (err: any) {
+      if (err.response?.data?.error === 'Invalid or expired challenge') {
+        setChallengeToken('');
+        setError('Your sign in attempt expired. Please log in again.');
+      } else {
+        setError('Invalid verification code.');
+      }
+    }
+  };
+
+  if (challengeToken) {
+    return (
+      <div className="container mx-auto max-w-sm mt-20">
+        <h1 className="text-3xl font-bold text-center mb-8 text-cyan-400">Two-Factor Authentication</h1>
+        <form onSubmit={handleTwoFactorSubmit} className="bg-gray-800 p-8 rounded-lg shadow-lg">
+          {error && <p className="bg-red-500/20 text-red-400 p-3 rounded mb-4">{error}</p>}
+          <div className="mb-6">
+            <label className="block text-gray-300 mb-2" htmlFor="code">{useRecoveryCode ? 'Recovery code' : 'Authenticator code'}</label>
+            <input type="text" id="code" autoComplete="one-time-code" value={code} onChange={(e) => setCode(e.target.value)} className="w-full bg-gray-700 p-3 rounded-md focus:outline-none focus:ring-2 focus:ring-cyan-400" required />
+          </div>
+          <button type="submit" className="w-full bg-cyan-500 hover:bg-cyan-600 text-white font-bold py-3 rounded-md transition duration-300">Verify</button>
+          <button type="button" onClick={() => { setUseRecoveryCode(!useRecoveryCode); setCode(''); }} className="w-full mt-4 text-sm text-cyan-400 hover:underline">
+            {useRecoveryCode ? 'Use an authenticator code instead' : 'Use a recovery code instead'}
+          </button>
+        </form>
+      </div>
+    );
+  }
+
   return (
     <div className="container mx-auto max-w-sm mt-20">
       <h1 className="text-3xl font-bold text-center mb-8 text-cyan-400">Login to CineLume</h1>
       <form onSubmit={handleSubmit} className="bg-gray-800 p-8 rounded-lg shadow-lg">
         {error && <p className="bg-red-500/20 text-red-400 p-3 rounded mb-4">{error}</p>}
         <div className="mb-4">
-          <label className="block text-gray-300 mb-2" htmlFor="email">Email</label>
-          <input type="email" id="email" value={email} onChange={(e) => setEmail(e.target.value)} className="w-full bg-gray-700 p-3 rounded-md focus:outline-none focus:ring-2 focus:ring-cyan-400" required />
+          <label className="block text-gray-300 mb-2" htmlFor="identifier">Email or username</label>
+          <input type="text" id="identifier" autoComplete="username" value={identifier} onChange={(e) => setIdentifier(e.target.value)} className="w-full bg-gray-700 p-3 rounded-md focus:outline-none focus:ring-2 focus:ring-cyan-400" required />
         </div>
         <div className="mb-6">
           <label className="block text-gray-300 mb-2" htmlFor="password">Password</label>
           <input type="password" id="password" value={password} onChange={(e) => setPassword(e.target.value)} className="w-full bg-gray-700 p-3 rounded-md focus:outline-none focus:ring-2 focus:ring-cyan-400" required />
         </div>
         <button type="submit" className="w-full bg-cyan-500 hover:bg-cyan-600 text-white font-bold py-3 rounded-md transition duration-300">Log In</button>
+        {providers.length > 0 && (
+          <div className="mt-6 space-y-3">
+            <p className="text-center text-gray-400 text-sm">or continue with</p>
+            {providers.map((provider) => (
+              <a key={provider} href={`/api/auth/${provider}/login`} className="block w-full text-center bg-gray-700 hover:bg-gray-600 text-white font-medium py-3 rounded-md capitalize transition duration-300">{provider}</a>
+            ))}
+          </div>
+        )}
       </form>
       <p className="text-center text-gray-400 mt-4">
+        <Link href="/forgot-password" className="text-cyan-400 hover:underline">Forgot your password?</Link>
+      </p>
+      <p className="text-center text-gray-400 mt-2">
         Don't have an account? <Link href="/register" className="text-cyan-400 hover:underline">Register here</Link>
       </p>
     </div>
@@ -13,6 +13,7 @@ interface WatchlistStat {
 }
 
 interface UserStats {
+  username: string;
   watchlistStats: WatchlistStat[];
   meanScore: number;
   totalEntries: number;
@@ -54,6 +55,11 @@ export default function ProfilePage() {
           api.get(`/users/${username}/stats`),
           api.get(`/users/${username}/reviews`)
         ]);
+
+        if (statsRes.data.username && statsRes.data.username !== username) {
+          router.replace(`/profile/${statsRes.data.username}`);
+          return;
+        }
         
         setStats(statsRes.data);
         setLatestReviews(reviewsRes.data);
@@ -122,7 +128,10 @@ export default function ProfilePage() {
     e.preventDefault();
     setPasswordMessage({ type: '', text: '' });
     try {
-      await api.put('/users/password', { currentPassword, newPassword });
+      const response = await api.put('/users/password', { currentPassword, newPassword });
+      if (response.data.token) {
+        localStorage.setItem('token', response.data.token);
+      }
       setPasswordMessage({ type: 'success', text: 'Password updated successfully!' });
       setCurrentPassword('');
       setNewPassword('');
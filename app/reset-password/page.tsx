@@ -0,0 +1,53 @@
+"use client";
+
+import { Suspense, useState } from 'react';
+import { useSearchParams } from 'next/navigation';
+import Link from 'next/link';
+import api from '@/lib/api';
+
+function ResetPassword() {
+  const searchParams = useSearchParams();
+  const token = searchParams.get('token') || '';
+  const [newPassword, setNewPassword] = useState('');
+  const [message, setMessage] = useState('');
+  const [error, setError] = useState('');
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError('');
+    setMessage('');
+    try {
+      const response = await api.post('/users/password/reset', { token, newPassword });
+      setMessage(response.data.message);
+      setNewPassword('');
+    } catch (err: any) {
+      setError(err.response?.data?.error || 'Failed to reset password.');
+    }
+  };
+
+  return (
+    <div className="container mx-auto max-w-sm mt-20">
+      <h1 className="text-3xl font-bold text-center mb-8 text-cyan-400">Reset Password</h1>
+      <form onSubmit={handleSubmit} className="bg-gray-800 p-8 rounded-lg shadow-lg">
+        {error && <p className="bg-red-500/20 text-red-400 p-3 rounded mb-4">{error}</p>}
+        {message && <p className="bg-green-500/20 text-green-400 p-3 rounded mb-4">{message}</p>}
+        <div className="mb-6">
+          <label className="block text-gray-300 mb-2" htmlFor="newPassword">New Password</label>
+          <input type="password" id="newPassword" minLength={8} value={newPassword} onChange={(e) => setNewPassword(e.target.value)} className="w-full bg-gray-700 p-3 rounded-md focus:outline-none focus:ring-2 focus:ring-cyan-400" required />
+        </div>
+        <button type="submit" disabled={!token} className="w-full bg-cyan-500 hover:bg-cyan-600 disabled:opacity-50 text-white font-bold py-3 rounded-md transition duration-300">Set New Password</button>
+      </form>
+      <p className="text-center text-gray-400 mt-4">
+        <Link href="/login" className="text-cyan-400 hover:underline">Back to login</Link>
+      </p>
+    </div>
+  );
+}
+
+export default function ResetPasswordPage() {
+  return (
+    <Suspense fallback={<div className="text-center p-10">Loading...</div>}>
+      <ResetPassword />
+    </Suspense>
+  );
+}
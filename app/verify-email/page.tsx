@@ -0,0 +1,50 @@
+"use client";
+
+import { Suspense, useEffect, useState } from 'react';
+import { useSearchParams } from 'next/navigation';
+import Link from 'next/link';
+import api from '@/lib/api';
+
+function VerifyEmail() {
+  const searchParams = useSearchParams();
+  const token = searchParams.get('token');
+  const [status, setStatus] = useState<'pending' | 'success' | 'error'>('pending');
+  const [message, setMessage] = useState('Verifying your email...');
+
+  useEffect(() => {
+    if (!token) {
+      setStatus('error');
+      setMessage('Verification link is missing a token.');
+      return;
+    }
+    api.post('/users/verify-email', { token })
+      .then(() => {
+        setStatus('success');
+        setMessage('Your email address has been confirmed.');
+      })
+      .catch((err) => {
+        setStatus('error');
+        setMessage(err.response?.data?.error || 'This verification link is invalid or has expired.');
+      });
+  }, [token]);
+
+  return (
+    <div className="container mx-auto max-w-sm mt-20">
+      <h1 className="text-3xl font-bold text-center mb-8 text-cyan-400">Email Verification</h1>
+      <div className="bg-gray-800 p-8 rounded-lg shadow-lg text-center">
+        <p className={status === 'error' ? 'text-red-400' : status === 'success' ? 'text-green-400' : 'text-gray-300'}>{message}</p>
+        {status !== 'pending' && (
+          <Link href="/" className="inline-block mt-6 text-cyan-400 hover:underline">Back to CineLume</Link>
+        )}
+      </div>
+    </div>
+  );
+}
+
+export default function VerifyEmailPage() {
+  return (
+    <Suspense fallback={<div className="text-center p-10">Loading...</div>}>
+      <VerifyEmail />
+    </Suspense>
+  );
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	osuser "os/user"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/accounts"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/audit"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/config"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/database"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/database/migrations"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/maintenance"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/models"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/passwords"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/store"
+)
+
+const usage = `Usage: cinelume-admin <command> [arguments]
+
+Commands:
+  migrate <command>                run a cinelume-migrate command (up, down [n], status)
+
+  user show <user>                 show an account, its watchlist and review totals
+  user disable <user>              block sign-in and sign the user out everywhere
+  user enable <user>               allow a disabled user to sign in again
+  user delete <user> --yes         permanently delete an account and its data
+  user reset-password <user>       set a temporary password and sign out everywhere
+  user set-role <user> <role>      change a user's role (user, moderator, admin)
+
+  purge-expired                    delete expired tokens, OAuth states, 2FA challenges
+                                   and stale login throttles, then permanently delete
+                                   accounts whose deletion grace period has ended
+  reindex                          rebuild the indexes behind user and username lookups
+  recompute-stats                  refresh planner statistics and print table totals
+
+<user> is a numeric ID, a username or an email address.
+Configuration is read the same way as the API server (environment, .env,
+.env.local and $CONFIG_FILE).`
+
+const temporaryPasswordBytes = 12
+
+type admin struct {
+	cfg       *config.Config
+	db        *sql.DB
+	stores    *store.Stores
+	audit     *audit.Logger
+	passwords *passwords.Manager
+}
+
+type command func(a *admin, ctx context.Context, args []string) error
+
+var commands = map[string]command{
+	"migrate":         (*admin).migrate,
+	"user":            (*admin).user,
+	"purge-expired":   (*admin).purgeExpired,
+	"reindex":         (*admin).reindex,
+	"recompute-stats": (*admin).recomputeStats,
+}
+
+var errUsage = errors.New("invalid arguments")
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	run, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load("")
+	if err != nil {
+		log.Fatalf("Unable to load configuration: %v", err)
+	}
+
+	ctx := context.Background()
+	db, err := database.Connect(ctx, cfg.Database)
+	if err != nil {
+		log.Fatalf("Unable to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	a := &admin{
+		cfg:       cfg,
+		db:        db,
+		stores:    store.NewPostgres(db),
+		audit:     audit.New(db),
+		passwords: passwords.Named(cfg.PasswordHasher, cfg.Argon2),
+	}
+	if err := run(a, ctx, os.Args[2:]); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
+		}
+		db.Close()
+		log.Fatalf("%s failed: %v", os.Args[1], err)
+	}
+}
+
+func (a *admin) migrate(ctx context.Context, args []string) error {
+	err := migrations.RunCommand(ctx, a.db, args, os.Stdout)
+	if errors.Is(err, migrations.ErrUnknownCommand) {
+		return errUsage
+	}
+	return err
+}
+
+func (a *admin) user(ctx context.Context, args []string) error {
+	if len(args) < 2 {
+		return errUsage
+	}
+
+	u, err := a.lookup(ctx, args[1])
+	if err != nil {
+		return err
+	}
+
+	switch action, rest := args[0], args[2:]; {
+	case action == "show" && len(rest) == 0:
+		return a.showUser(ctx, u)
+	case action == "disable" && len(rest) == 0:
+		return a.setDisabled(ctx, u, true)
+	case action == "enable" && len(rest) == 0:
+		return a.setDisabled(ctx, u, false)
+	case action == "delete" && len(rest) == 1 && rest[0] == "--yes":
+		return a.deleteUser(ctx, u)
+	case action == "delete":
+		return fmt.Errorf("refusing to delete %s (id %d) without --yes", u.Username, u.ID)
+	case action == "reset-password" && len(rest) == 0:
+		return a.resetPassword(ctx, u)
+	case action == "set-role" && len(rest) == 1:
+		return a.setRole(ctx, u, rest[0])
+	}
+	return errUsage
+}
+
+func (a *admin) lookup(ctx context.Context, identifier string) (*models.User, error) {
+	userID, err := strconv.Atoi(identifier)
+	if err != nil {
+		creds, err := a.stores.Users.FindCredentials(ctx, strings.TrimSpace(identifier))
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return nil, fmt.Errorf("no user matches %q", identifier)
+			}
+			return nil, err
+		}
+		userID = creds.UserID
+	}
+
+	u, err := a.stores.Users.Get(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("no user matches %q", identifier)
+	}
+	return u, err
+}
+
+func (a *admin) showUser(ctx context.Context, u *models.User) error {
+	counts, err := a.stores.Watchlist.StatusCounts(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	summary, err := a.stores.Reviews.Summary(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+
+	field := func(name string, value interface{}) {
+		fmt.Printf("%-18s %v\n", name+":", value)
+	}
+	field("ID", u.ID)
+	field("Username", u.Username)
+	field("Email", u.Email)
+	field("Email verified", u.EmailVerified)
+	field("Role", u.Role)
+	field("Two-factor", u.TwoFactorEnabled)
+	field("Created", u.CreatedAt.Format(time.RFC3339))
+	if u.DisabledAt != nil {
+		field("Disabled", u.DisabledAt.Format(time.RFC3339))
+	}
+	if u.DeletionScheduledAt != nil {
+		field("Deletion due", u.DeletionScheduledAt.Format(time.RFC3339))
+	}
+	for _, c := range counts {
+		field("Watchlist "+c.Status, c.Count)
+	}
+	field("Reviews", summary.Count)
+	if summary.Count > 0 {
+		field("Mean score", fmt.Sprintf("%.2f", summary.MeanScore))
+	}
+	return nil
+}
+
+func (a *admin) setDisabled(ctx context.Context, u *models.User, disabled bool) error {
+	changed, err := a.stores.Users.SetDisabled(ctx, u.ID, disabled)
+	if err != nil {
+		return err
+	}
+
+	state, event := "enabled", audit.EventAccountEnabled
+	if disabled {
+		state, event = "disabled", audit.EventAccountDisabled
+	}
+	if !changed {
+		fmt.Printf("%s (id %d) is already %s\n", u.Username, u.ID, state)
+		return nil
+	}
+
+	a.record(ctx, event, u.ID, nil)
+	if disabled {
+		fmt.Printf("Disabled %s (id %d) and signed them out of every session\n", u.Username, u.ID)
+	} else {
+		fmt.Printf("Enabled %s (id %d)\n", u.Username, u.ID)
+	}
+	return nil
+}
+
+func (a *admin) deleteUser(ctx context.Context, u *models.User) error {
+	if err := a.stores.Users.Delete(ctx, u.ID); err != nil {
+		return err
+	}
+	a.record(ctx, audit.EventAccountDeleted, u.ID, nil)
+	fmt.Printf("Deleted %s (id %d)\n", u.Username, u.ID)
+	return nil
+}
+
+func (a *admin) resetPassword(ctx context.Context, u *models.User) error {
+	temporary, err := auth.GenerateToken(temporaryPasswordBytes)
+	if err != nil {
+		return err
+	}
+	hash, err := a.passwords.Hash(temporary)
+	if err != nil {
+		return err
+	}
+	if err := a.stores.Users.SetPasswordHash(ctx, u.ID, hash); err != nil {
+		return err
+	}
+
+	a.record(ctx, audit.EventPasswordReset, u.ID, nil)
+	fmt.Printf("Reset the password for %s (id %d) and signed them out of every session.\n", u.Username, u.ID)
+	fmt.Printf("Temporary password: %s\n", temporary)
+	return nil
+}
+
+func (a *admin) setRole(ctx context.Context, u *models.User, name string) error {
+	role, ok := auth.ParseRole(name)
+	if !ok {
+		return fmt.Errorf("unknown role %q", name)
+	}
+
+	changed, err := a.stores.Users.SetRole(ctx, u.ID, string(role))
+	if err != nil {
+		return err
+	}
+	if !changed {
+		fmt.Printf("%s (id %d) already has role %s\n", u.Username, u.ID, role)
+		return nil
+	}
+
+	a.record(ctx, audit.EventRoleChanged, u.ID, map[string]interface{}{"role": role, "previousRole": u.Role})
+	fmt.Printf("Changed the role of %s (id %d) from %s to %s\n", u.Username, u.ID, u.Role, role)
+	return nil
+}
+
+func (a *admin) purgeExpired(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		return errUsage
+	}
+
+	results, err := maintenance.PurgeExpired(ctx, a.db)
+	for _, r := range results {
+		fmt.Printf("%-28s %d row(s) removed\n", r.Table, r.Rows)
+	}
+	if err != nil {
+		return err
+	}
+
+	purged, err := accounts.PurgeDue(ctx, a.db)
+	fmt.Printf("%-28s %d account(s) deleted\n", "users", purged)
+	return err
+}
+
+func (a *admin) reindex(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		return errUsage
+	}
+
+	if err := maintenance.Reindex(ctx, a.db); err != nil {
+		return err
+	}
+	fmt.Println("Rebuilt user, username history, watchlist and review indexes")
+	return nil
+}
+
+func (a *admin) recomputeStats(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		return errUsage
+	}
+
+	results, err := maintenance.Analyze(ctx, a.db)
+	for _, r := range results {
+		fmt.Printf("%-28s %d row(s)\n", r.Table, r.Rows)
+	}
+	return err
+}
+
+func (a *admin) record(ctx context.Context, event audit.Event, userID int, metadata map[string]interface{}) {
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadata["source"] = "cinelume-admin"
+	if current, err := osuser.Current(); err == nil {
+		metadata["operator"] = current.Username
+	}
+	a.audit.Log(ctx, audit.Entry{Event: event, UserID: &userID, UserAgent: "cinelume-admin", Metadata: metadata})
+}
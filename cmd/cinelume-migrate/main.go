@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/config"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/database"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/database/migrations"
+)
+
+const usage = "Usage: cinelume-migrate <command>\n\nCommands:\n" + migrations.Commands
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.LoadDatabase("")
+	if err != nil {
+		log.Fatalf("Unable to load configuration: %v", err)
+	}
+	ctx := context.Background()
+	db, err := database.Connect(ctx, cfg)
+	if err != nil {
+		log.Fatalf("Unable to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	if err := migrations.RunCommand(ctx, db, os.Args[1:], os.Stdout); err != nil {
+		if errors.Is(err, migrations.ErrUnknownCommand) {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
+		}
+		db.Close()
+		log.Fatal(err)
+	}
+}
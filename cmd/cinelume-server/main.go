@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/auth"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/config"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/database"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/database/migrations"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/routes"
+	"github.com/DarrenAnthonyBeltham/cinelume/api/pkg/store"
+)
+
+const migrateTimeout = 5 * time.Minute
+
+func main() {
+	configFile := flag.String("config", "", "dotenv-style config file (default $CONFIG_FILE)")
+	addr := flag.String("addr", "", "address to listen on (default $ADDR, or :$PORT, or :8080)")
+	migrate := flag.Bool("migrate", false, "apply pending migrations before serving (default $MIGRATE_ON_START)")
+	drain := flag.Duration("shutdown-timeout", 0, "how long to wait for in-flight requests on shutdown (default $SHUTDOWN_TIMEOUT or 15s)")
+	flag.Parse()
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatalf("Unable to load configuration: %v", err)
+	}
+	if *addr != "" {
+		cfg.Server.Addr = *addr
+	}
+	if *migrate {
+		cfg.Server.MigrateOnStart = true
+	}
+	if *drain > 0 {
+		cfg.Server.ShutdownTimeout = *drain
+	}
+	for _, warning := range cfg.Warnings() {
+		log.Printf("Config: %s", warning)
+	}
+
+	keys, err := auth.LoadKeySet(cfg.JWT)
+	if err != nil {
+		log.Fatalf("Unable to load JWT keys: %v", err)
+	}
+
+	conn := database.New(cfg.Database)
+	if cfg.Server.MigrateOnStart {
+		if !conn.Configured() {
+			log.Fatalf("Cannot run migrations: %v", database.ErrNotConfigured)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), migrateTimeout)
+		applied, err := migrations.Up(ctx, conn.DB)
+		cancel()
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		log.Printf("Applied %d migration(s)", applied)
+	}
+
+	server := &http.Server{
+		Addr:              cfg.Server.Addr,
+		Handler:           routes.SetupRoutes(cfg, conn, store.NewPostgres(conn.DB), keys),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("Listening on %s", cfg.Server.Addr)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server failed: %v", err)
+		}
+	case <-ctx.Done():
+		stop()
+		log.Printf("Shutting down, draining requests for up to %s", cfg.Server.ShutdownTimeout)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Forced shutdown: %v", err)
+		}
+	}
+
+	if conn.DB != nil {
+		conn.DB.Close()
+	}
+}
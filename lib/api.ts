@@ -16,4 +16,40 @@ api.interceptors.request.use(
   (error) => Promise.reject(error)
 );
 
+let refreshing: Promise<string | null> | null = null;
+
+const refreshAccessToken = async (): Promise<string | null> => {
+  const refreshToken = localStorage.getItem('refreshToken');
+  if (!refreshToken) return null;
+  try {
+    const response = await axios.post('/api/users/refresh', { refreshToken }, { withCredentials: true });
+    localStorage.setItem('token', response.data.token);
+    localStorage.setItem('refreshToken', response.data.refreshToken);
+    return response.data.token;
+  } catch {
+    localStorage.removeItem('token');
+    localStorage.removeItem('refreshToken');
+    return null;
+  }
+};
+
+api.interceptors.response.use(
+  (response) => response,
+  async (error) => {
+    const original = error.config;
+    if (typeof window === 'undefined' || error.response?.status !== 401 || !original || original._retry) {
+      return Promise.reject(error);
+    }
+    if (original.url?.startsWith('/users/login') || original.url?.startsWith('/users/refresh')) {
+      return Promise.reject(error);
+    }
+    original._retry = true;
+    refreshing = refreshing ?? refreshAccessToken().finally(() => { refreshing = null; });
+    const token = await refreshing;
+    if (!token) return Promise.reject(error);
+    original.headers['Authorization'] = `Bearer ${token}`;
+    return api(original);
+  }
+);
+
 export default api;